@@ -0,0 +1,281 @@
+package main
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// responseCache menyimpan body response GET yang sukses di memori proses,
+// kunci = path + query string yang sudah diurutkan.
+// Entry dibuang saat TTL habis, saat total ukuran melebihi maxBytes (paling lama tidak dipakai dulu)
+// atau di-purge lewat /admin/cache/purge. nil berarti cache nonaktif.
+type responseCache struct {
+	ttl      time.Duration
+	maxBytes int
+	metrics  *metrics
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List // depan = paling baru dipakai
+	bytes   int
+}
+
+type cacheEntry struct {
+	key         string
+	scope       *cacheScope
+	contentType string
+	disposition string // Content-Disposition untuk file export
+	body        []byte
+	storedAt    time.Time
+	expiresAt   time.Time
+}
+
+func newResponseCache(cfg CacheConfig, m *metrics) *responseCache {
+	if cfg.TTL <= 0 {
+		return nil
+	}
+	return &responseCache{
+		ttl:      time.Duration(cfg.TTL),
+		maxBytes: cfg.MaxBytes,
+		metrics:  m,
+		entries:  make(map[string]*list.Element),
+		lru:      list.New(),
+	}
+}
+
+func cacheKey(r *http.Request) string {
+	return r.URL.Path + "?" + r.URL.Query().Encode()
+}
+
+// cacheScope nama_tagging dan tahun data di satu entry, dipakai purge.
+// Laporan diisi dari query string; detail program unggulan tidak punya parameter itu,
+// handler mengisi tahun dari pokin lewat addCacheTahun dan nama_tagging dibiarkan kosong
+// (cocok dengan purge nama_tagging apa pun).
+type cacheScope struct {
+	namaTagging string
+	tahuns      map[string]bool
+}
+
+type cacheScopeKey struct{}
+
+// addCacheTahun mencatat tahun pokin response ke entry cache request ini, tanpa cache tidak berbuat apa-apa
+func addCacheTahun(ctx context.Context, pokins []Pokin) {
+	scope, ok := ctx.Value(cacheScopeKey{}).(*cacheScope)
+	if !ok {
+		return
+	}
+	for _, p := range pokins {
+		scope.tahuns[strconv.Itoa(int(p.Tahun))] = true
+	}
+}
+
+// match purge, parameter kosong = semua. Entry tanpa tahun (response kosong) ikut dibuang.
+func (s *cacheScope) match(namaTagging, tahun string) bool {
+	return (namaTagging == "" || s.namaTagging == "" || s.namaTagging == namaTagging) &&
+		(tahun == "" || len(s.tahuns) == 0 || s.tahuns[tahun])
+}
+
+// middleware melayani request dari cache atau menjalankan handler lalu menyimpan hasilnya.
+// Hanya status 200 yang disimpan, handler bisa menolak dengan header Cache-Control: no-store.
+// Export csv dilewatkan karena di-stream: jika terputus di tengah, body yang terekam tidak lengkap.
+// Export pdf juga dilewatkan karena tanggal tanda tangannya tanggal saat dibuat.
+func (c *responseCache) middleware(route string, next http.HandlerFunc) http.HandlerFunc {
+	if c == nil {
+		return next
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		if format := r.URL.Query().Get("format"); format == formatCSV || format == formatPDF {
+			next(w, r)
+			return
+		}
+
+		key := cacheKey(r)
+		if e, ok := c.get(key); ok {
+			c.metrics.cacheRequests.inc(route, "hit")
+			w.Header().Set("Content-Type", e.contentType)
+			if e.disposition != "" {
+				w.Header().Set("Content-Disposition", e.disposition)
+			}
+			w.Header().Set("X-Cache", "HIT")
+			w.Header().Set("Age", strconv.Itoa(int(time.Since(e.storedAt).Seconds())))
+			w.WriteHeader(http.StatusOK)
+			w.Write(e.body)
+			return
+		}
+		c.metrics.cacheRequests.inc(route, "miss")
+
+		q := r.URL.Query()
+		scope := &cacheScope{namaTagging: q.Get("nama_tagging"), tahuns: make(map[string]bool)}
+		if tahun := q.Get("tahun"); tahun != "" {
+			scope.tahuns[tahun] = true
+		}
+		r = r.WithContext(context.WithValue(r.Context(), cacheScopeKey{}, scope))
+
+		rec := &cacheRecorder{ResponseWriter: w, limit: c.maxBytes}
+		next(rec, r)
+
+		// client putus: body bisa terpotong
+		if !rec.cacheable() || rec.overflow || r.Context().Err() != nil {
+			return
+		}
+		c.put(&cacheEntry{
+			key:         key,
+			scope:       scope,
+			contentType: w.Header().Get("Content-Type"),
+			disposition: w.Header().Get("Content-Disposition"),
+			body:        rec.buf.Bytes(),
+		})
+	}
+}
+
+func (c *responseCache) get(key string) (*cacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	e := el.Value.(*cacheEntry)
+	if time.Now().After(e.expiresAt) {
+		c.remove(el, "expired")
+		return nil, false
+	}
+	c.lru.MoveToFront(el)
+	return e, true
+}
+
+func (c *responseCache) put(e *cacheEntry) {
+	size := e.size()
+	if size > c.maxBytes {
+		return
+	}
+	e.storedAt = time.Now()
+	e.expiresAt = e.storedAt.Add(c.ttl)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.entries[e.key]; ok {
+		c.remove(el, "replaced")
+	}
+	c.entries[e.key] = c.lru.PushFront(e)
+	c.bytes += size
+
+	for c.bytes > c.maxBytes {
+		c.remove(c.lru.Back(), "size")
+	}
+}
+
+// remove dipanggil dengan mu terkunci
+func (c *responseCache) remove(el *list.Element, reason string) {
+	e := c.lru.Remove(el).(*cacheEntry)
+	delete(c.entries, e.key)
+	c.bytes -= e.size()
+	c.metrics.cacheEvictions.inc(reason)
+}
+
+// purge membuang entry dengan nama_tagging dan tahun tertentu, parameter kosong = semua
+func (c *responseCache) purge(namaTagging, tahun string) int {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	purged := 0
+	for el := c.lru.Front(); el != nil; {
+		next := el.Next()
+		e := el.Value.(*cacheEntry)
+		if e.scope.match(namaTagging, tahun) {
+			c.remove(el, "purge")
+			purged++
+		}
+		el = next
+	}
+	return purged
+}
+
+func (c *responseCache) stats() (entries, bytes int) {
+	if c == nil {
+		return 0, 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries), c.bytes
+}
+
+func (e *cacheEntry) size() int {
+	return len(e.key) + len(e.body)
+}
+
+// purgeHandler POST /admin/cache/purge?nama_tagging=..&tahun=..
+func (c *responseCache) purgeHandler(w http.ResponseWriter, r *http.Request) {
+	namaTagging := r.URL.Query().Get("nama_tagging")
+	tahun := r.URL.Query().Get("tahun")
+	if tahun != "" {
+		if _, err := strconv.Atoi(tahun); err != nil {
+			writeError(w, http.StatusBadRequest, errCodeInvalidParam, "parameter tidak valid",
+				FieldError{Field: "tahun", Message: "harus berupa angka"})
+			return
+		}
+	}
+
+	purged := c.purge(namaTagging, tahun)
+	loggerFrom(r.Context()).Info("cache purge", "nama_tagging", namaTagging, "tahun", tahun, "purged", purged)
+
+	writeJSON(w, http.StatusOK, Response{
+		Status:  http.StatusOK,
+		Message: "Cache dibersihkan",
+		Data:    map[string]int{"purged": purged},
+	})
+}
+
+// cacheRecorder meneruskan response ke client sambil menyalin body untuk disimpan,
+// salinan dilepas jika body melebihi limit
+type cacheRecorder struct {
+	http.ResponseWriter
+	status   int
+	limit    int
+	buf      bytes.Buffer
+	overflow bool
+}
+
+// WriteHeader menandai X-Cache: MISS hanya jika response akan disimpan
+func (rec *cacheRecorder) WriteHeader(code int) {
+	if rec.status != 0 {
+		return
+	}
+	rec.status = code
+	if rec.cacheable() {
+		rec.Header().Set("X-Cache", "MISS")
+	}
+	rec.ResponseWriter.WriteHeader(code)
+}
+
+func (rec *cacheRecorder) cacheable() bool {
+	return rec.status == http.StatusOK && !strings.Contains(rec.Header().Get("Cache-Control"), "no-store")
+}
+
+func (rec *cacheRecorder) Write(b []byte) (int, error) {
+	if rec.status == 0 {
+		rec.WriteHeader(http.StatusOK)
+	}
+	if !rec.overflow {
+		if rec.buf.Len()+len(b) > rec.limit {
+			rec.overflow = true
+			rec.buf = bytes.Buffer{}
+		} else {
+			rec.buf.Write(b)
+		}
+	}
+	return rec.ResponseWriter.Write(b)
+}
+
+func (rec *cacheRecorder) Unwrap() http.ResponseWriter { return rec.ResponseWriter }
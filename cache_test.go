@@ -0,0 +1,201 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// cachedHandler handler uji yang menghitung pemanggilan, body = path + query
+func cachedHandler(cache *responseCache, calls *int, status int) http.HandlerFunc {
+	return cache.middleware("/test", func(w http.ResponseWriter, r *http.Request) {
+		*calls++
+		if status != http.StatusOK {
+			writeError(w, status, errCodeInvalidParam, "parameter tidak valid")
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte(r.URL.String()))
+	})
+}
+
+func getCached(t *testing.T, h http.Handler, target string) *httptest.ResponseRecorder {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+	return rec
+}
+
+func TestCacheHitMiss(t *testing.T) {
+	cache := newResponseCache(CacheConfig{TTL: Duration(time.Minute), MaxBytes: 1 << 20}, newMetrics())
+	calls := 0
+	h := cachedHandler(cache, &calls, http.StatusOK)
+
+	first := getCached(t, h, "/test?tahun=2025&nama_tagging=A")
+	if got := first.Header().Get("X-Cache"); got != "MISS" {
+		t.Errorf("request pertama X-Cache = %q, want MISS", got)
+	}
+	// urutan query berbeda, kunci sama
+	second := getCached(t, h, "/test?nama_tagging=A&tahun=2025")
+	if got := second.Header().Get("X-Cache"); got != "HIT" {
+		t.Errorf("request kedua X-Cache = %q, want HIT", got)
+	}
+	if calls != 1 {
+		t.Errorf("handler dipanggil %d kali, want 1", calls)
+	}
+	if second.Body.String() != first.Body.String() || second.Header().Get("Content-Type") != "text/plain" {
+		t.Errorf("response HIT berbeda: %q %q", second.Header().Get("Content-Type"), second.Body)
+	}
+}
+
+func TestCacheSkipsUncacheable(t *testing.T) {
+	cache := newResponseCache(CacheConfig{TTL: Duration(time.Minute), MaxBytes: 1 << 20}, newMetrics())
+
+	tests := []struct {
+		name    string
+		handler func(calls *int) http.HandlerFunc
+		target  string
+	}{
+		{name: "status 400", target: "/test?tahun=x", handler: func(calls *int) http.HandlerFunc {
+			return cachedHandler(cache, calls, http.StatusBadRequest)
+		}},
+		{name: "no-store", target: "/test?partial=true", handler: func(calls *int) http.HandlerFunc {
+			return cache.middleware("/test", func(w http.ResponseWriter, r *http.Request) {
+				*calls++
+				w.Header().Set("Cache-Control", "no-store")
+				w.Write([]byte("partial"))
+			})
+		}},
+		{name: "format pdf", target: "/test?format=pdf", handler: func(calls *int) http.HandlerFunc {
+			return cachedHandler(cache, calls, http.StatusOK)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			h := tt.handler(&calls)
+			for range 2 {
+				if got := getCached(t, h, tt.target).Header().Get("X-Cache"); got != "" {
+					t.Errorf("X-Cache = %q, want kosong", got)
+				}
+			}
+			if calls != 2 {
+				t.Errorf("handler dipanggil %d kali, want 2", calls)
+			}
+		})
+	}
+	if entries, _ := cache.stats(); entries != 0 {
+		t.Errorf("entries = %d, want 0", entries)
+	}
+}
+
+func TestCacheTTL(t *testing.T) {
+	cache := newResponseCache(CacheConfig{TTL: Duration(time.Minute), MaxBytes: 1 << 20}, newMetrics())
+	calls := 0
+	h := cachedHandler(cache, &calls, http.StatusOK)
+
+	getCached(t, h, "/test?tahun=2025")
+	for _, el := range cache.entries {
+		el.Value.(*cacheEntry).expiresAt = time.Now().Add(-time.Second)
+	}
+	if got := getCached(t, h, "/test?tahun=2025").Header().Get("X-Cache"); got != "MISS" {
+		t.Errorf("setelah TTL X-Cache = %q, want MISS", got)
+	}
+	if calls != 2 {
+		t.Errorf("handler dipanggil %d kali, want 2", calls)
+	}
+}
+
+func TestCacheMaxBytes(t *testing.T) {
+	// tiap entry: kunci "/test?tahun=202N" + body "/test?tahun=202N" = 32 byte
+	cache := newResponseCache(CacheConfig{TTL: Duration(time.Minute), MaxBytes: 70}, newMetrics())
+	calls := 0
+	h := cachedHandler(cache, &calls, http.StatusOK)
+
+	getCached(t, h, "/test?tahun=2021")
+	getCached(t, h, "/test?tahun=2022")
+	// 2021 dipakai lagi, 2022 jadi yang paling lama
+	getCached(t, h, "/test?tahun=2021")
+	getCached(t, h, "/test?tahun=2023")
+
+	if entries, size := cache.stats(); entries != 2 || size > 70 {
+		t.Fatalf("entries = %d, bytes = %d, want 2 entry <= 70 byte", entries, size)
+	}
+	if got := getCached(t, h, "/test?tahun=2021").Header().Get("X-Cache"); got != "HIT" {
+		t.Errorf("2021 X-Cache = %q, want HIT", got)
+	}
+	if got := getCached(t, h, "/test?tahun=2022").Header().Get("X-Cache"); got != "MISS" {
+		t.Errorf("2022 X-Cache = %q, want MISS (dibuang)", got)
+	}
+
+	// body lebih besar dari MaxBytes tidak disimpan
+	big := cache.middleware("/test", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(strings.Repeat("x", 100)))
+	})
+	if got := getCached(t, big, "/test?besar=1").Header().Get("X-Cache"); got != "MISS" {
+		t.Errorf("body besar X-Cache = %q, want MISS", got)
+	}
+	if _, ok := cache.entries["/test?besar=1"]; ok {
+		t.Error("body melebihi MaxBytes ikut disimpan")
+	}
+}
+
+func TestCachePurge(t *testing.T) {
+	fx, err := loadDemoFixture("")
+	if err != nil {
+		t.Fatalf("fixture demo: %v", err)
+	}
+	tags, err := loadTagRegistry("")
+	if err != nil {
+		t.Fatalf("tag registry: %v", err)
+	}
+	srv := newServer(NewMemoryRepository(fx, tags), newMetrics(), ReportConfig{})
+	cache := newResponseCache(CacheConfig{TTL: Duration(time.Minute), MaxBytes: 1 << 20}, newMetrics())
+	rt := newRouter()
+	rt.handle(http.MethodGet, "/laporan/tagging_pokin", cache.middleware("/laporan/tagging_pokin", srv.laporanHandler))
+	rt.handle(http.MethodGet, "/tagging/getDetail/{kode}", cache.middleware("/tagging/getDetail/{kode}", srv.getDetailHandler))
+
+	laporan2025 := "/laporan/tagging_pokin?nama_tagging=Zero+Stunting&tahun=2025"
+	laporan2024 := "/laporan/tagging_pokin?nama_tagging=Zero+Stunting&tahun=2024"
+	laporanLain := "/laporan/tagging_pokin?nama_tagging=Program+Unggulan+Bupati&tahun=2025"
+	detail := "/tagging/getDetail/PU-02"
+
+	tests := []struct {
+		name        string
+		namaTagging string
+		tahun       string
+		purged      []string
+		kept        []string
+	}{
+		{name: "tahun", tahun: "2025", purged: []string{laporan2025, laporanLain, detail}, kept: []string{laporan2024}},
+		{name: "nama tagging", namaTagging: "Zero Stunting", purged: []string{laporan2025, laporan2024, detail}, kept: []string{laporanLain}},
+		{name: "nama tagging dan tahun", namaTagging: "Zero Stunting", tahun: "2024", purged: []string{laporan2024}, kept: []string{laporan2025, laporanLain, detail}},
+		{name: "semua", purged: []string{laporan2025, laporan2024, laporanLain, detail}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache.purge("", "")
+			for _, target := range []string{laporan2025, laporan2024, laporanLain, detail} {
+				if rec := getCached(t, rt, target); rec.Code != http.StatusOK || rec.Header().Get("X-Cache") != "MISS" {
+					t.Fatalf("%s: status %d, X-Cache %q", target, rec.Code, rec.Header().Get("X-Cache"))
+				}
+			}
+
+			if n := cache.purge(tt.namaTagging, tt.tahun); n != len(tt.purged) {
+				t.Errorf("purged = %d, want %d", n, len(tt.purged))
+			}
+			for _, target := range tt.purged {
+				if got := getCached(t, rt, target).Header().Get("X-Cache"); got != "MISS" {
+					t.Errorf("%s X-Cache = %q, want MISS", target, got)
+				}
+			}
+			for _, target := range tt.kept {
+				if got := getCached(t, rt, target).Header().Get("X-Cache"); got != "HIT" {
+					t.Errorf("%s X-Cache = %q, want HIT", target, got)
+				}
+			}
+		})
+	}
+}
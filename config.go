@@ -0,0 +1,380 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// Config adalah konfigurasi efektif service.
+// Urutan prioritas: default < file konfigurasi (-config / CONFIG_FILE) < env < flag.
+type Config struct {
+	Port           int           `json:"port"`
+	Demo           bool          `json:"demo"`
+	FixturePath    string        `json:"fixture_path"`
+	TagSourcesPath string        `json:"tag_sources_path"`
+	DB             DBConfig      `json:"db"`
+	Server         ServerConfig  `json:"server"`
+	Timeouts       TimeoutConfig `json:"timeouts"`
+	Log            LogConfig     `json:"log"`
+	Cache          CacheConfig   `json:"cache"`
+	Report         ReportConfig  `json:"report"`
+	// token Bearer untuk endpoint /admin/, kosong = endpoint admin nonaktif
+	AdminToken string `json:"admin_token"`
+}
+
+type DBConfig struct {
+	DSN             string   `json:"dsn"`
+	MaxOpenConns    int      `json:"max_open_conns"`
+	MaxIdleConns    int      `json:"max_idle_conns"`
+	ConnMaxIdleTime Duration `json:"conn_max_idle_time"`
+	ConnMaxLifetime Duration `json:"conn_max_lifetime"`
+	// timeout tiap ping, ping diulang dengan jeda exponential backoff sampai berhasil
+	PingTimeout         Duration `json:"ping_timeout"`
+	RetryInitialBackoff Duration `json:"retry_initial_backoff"`
+	RetryMaxBackoff     Duration `json:"retry_max_backoff"`
+}
+
+type ServerConfig struct {
+	ReadHeaderTimeout Duration `json:"read_header_timeout"`
+	ReadTimeout       Duration `json:"read_timeout"`
+	WriteTimeout      Duration `json:"write_timeout"`
+	IdleTimeout       Duration `json:"idle_timeout"`
+	ShutdownTimeout   Duration `json:"shutdown_timeout"`
+	// jeda antara /ready tidak siap dan berhenti menerima koneksi,
+	// supaya load balancer sempat mengeluarkan instance ini
+	ShutdownGrace Duration `json:"shutdown_grace"`
+}
+
+type LogConfig struct {
+	// json atau text
+	Format string `json:"format"`
+	// debug, info, warn, error
+	Level string `json:"level"`
+}
+
+// cache response laporan di memori proses
+type CacheConfig struct {
+	// umur entry, 0 = cache nonaktif
+	TTL Duration `json:"ttl"`
+	// total ukuran body yang disimpan, entry terlama dibuang jika penuh
+	MaxBytes int `json:"max_bytes"`
+}
+
+// kop dan blok tanda tangan laporan PDF
+type ReportConfig struct {
+	// baris kop, misal PEMERINTAH KABUPATEN ... dan nama perangkat daerah
+	Instansi string `json:"instansi"`
+	Unit     string `json:"unit"`
+	Alamat   string `json:"alamat"`
+	// tempat di atas tanggal tanda tangan
+	Kota string `json:"kota"`
+	// penandatangan, kosong = baris nama dan NIP dikosongkan untuk diisi tangan
+	Jabatan string `json:"jabatan"`
+	Nama    string `json:"nama"`
+	NIP     string `json:"nip"`
+}
+
+// batas waktu per endpoint, dipakai withTimeout
+type TimeoutConfig struct {
+	Laporan     Duration `json:"laporan"`
+	Detail      Duration `json:"detail"`
+	DetailBatch Duration `json:"detail_batch"`
+	// ping database di /health
+	Health Duration `json:"health"`
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Port: 8080,
+		DB: DBConfig{
+			MaxOpenConns:        70,
+			MaxIdleConns:        35,
+			ConnMaxIdleTime:     Duration(5 * time.Minute),
+			ConnMaxLifetime:     Duration(60 * time.Minute),
+			PingTimeout:         Duration(10 * time.Second),
+			RetryInitialBackoff: Duration(1 * time.Second),
+			RetryMaxBackoff:     Duration(30 * time.Second),
+		},
+		Server: ServerConfig{
+			ReadHeaderTimeout: Duration(10 * time.Second),
+			ReadTimeout:       Duration(30 * time.Second),
+			WriteTimeout:      Duration(2 * time.Minute),
+			IdleTimeout:       Duration(2 * time.Minute),
+			ShutdownTimeout:   Duration(30 * time.Second),
+			ShutdownGrace:     Duration(5 * time.Second),
+		},
+		Timeouts: TimeoutConfig{
+			Laporan:     Duration(60 * time.Second),
+			Detail:      Duration(30 * time.Second),
+			DetailBatch: Duration(60 * time.Second),
+			Health:      Duration(2 * time.Second),
+		},
+		Log: LogConfig{
+			Format: "json",
+			Level:  "info",
+		},
+		Cache: CacheConfig{
+			TTL:      Duration(5 * time.Minute),
+			MaxBytes: 64 << 20,
+		},
+		Report: ReportConfig{
+			Instansi: "PEMERINTAH DAERAH",
+			Unit:     "BADAN PERENCANAAN PEMBANGUNAN DAERAH",
+			Jabatan:  "Kepala Badan Perencanaan Pembangunan Daerah",
+		},
+	}
+}
+
+// setting menghubungkan satu field config dengan nama flag dan env var
+type setting struct {
+	flag  string
+	env   string
+	usage string
+	value flag.Value
+}
+
+func (c *Config) settings() []setting {
+	return []setting{
+		{"port", "PORT", "port HTTP", (*intValue)(&c.Port)},
+		{"demo", "DEMO", "jalankan dengan data fixture, tanpa PERENCANAAN_DB_URL", (*boolValue)(&c.Demo)},
+		{"fixture", "FIXTURE_PATH", "path fixture JSON untuk mode demo (default: fixture bawaan)", (*stringValue)(&c.FixturePath)},
+		{"tag-sources", "TAG_SOURCES_PATH", "path konfigurasi tag source JSON (default: konfigurasi bawaan)", (*stringValue)(&c.TagSourcesPath)},
+		{"admin-token", "ADMIN_TOKEN", "token Bearer endpoint /admin/ (kosong: endpoint admin nonaktif)", (*stringValue)(&c.AdminToken)},
+		{"db-dsn", "PERENCANAAN_DB_URL", "DSN database perencanaan", (*stringValue)(&c.DB.DSN)},
+		{"db-max-open-conns", "DB_MAX_OPEN_CONNS", "maksimal koneksi terbuka", (*intValue)(&c.DB.MaxOpenConns)},
+		{"db-max-idle-conns", "DB_MAX_IDLE_CONNS", "maksimal koneksi idle, tidak boleh melebihi max open", (*intValue)(&c.DB.MaxIdleConns)},
+		{"db-conn-max-idle-time", "DB_CONN_MAX_IDLE_TIME", "lama koneksi idle sebelum ditutup", &c.DB.ConnMaxIdleTime},
+		{"db-conn-max-lifetime", "DB_CONN_MAX_LIFETIME", "umur maksimal koneksi", &c.DB.ConnMaxLifetime},
+		{"db-ping-timeout", "DB_PING_TIMEOUT", "timeout tiap ping database", &c.DB.PingTimeout},
+		{"db-retry-initial-backoff", "DB_RETRY_INITIAL_BACKOFF", "jeda awal sebelum koneksi database diulang", &c.DB.RetryInitialBackoff},
+		{"db-retry-max-backoff", "DB_RETRY_MAX_BACKOFF", "jeda maksimal antar percobaan koneksi database", &c.DB.RetryMaxBackoff},
+		{"server-read-header-timeout", "SERVER_READ_HEADER_TIMEOUT", "batas waktu baca header request", &c.Server.ReadHeaderTimeout},
+		{"server-read-timeout", "SERVER_READ_TIMEOUT", "batas waktu baca request", &c.Server.ReadTimeout},
+		{"server-write-timeout", "SERVER_WRITE_TIMEOUT", "batas waktu tulis response", &c.Server.WriteTimeout},
+		{"server-idle-timeout", "SERVER_IDLE_TIMEOUT", "batas waktu koneksi keep-alive idle", &c.Server.IdleTimeout},
+		{"shutdown-timeout", "SHUTDOWN_TIMEOUT", "batas waktu menunggu request selesai saat shutdown", &c.Server.ShutdownTimeout},
+		{"shutdown-grace", "SHUTDOWN_GRACE", "jeda setelah /ready tidak siap sebelum server berhenti menerima koneksi", &c.Server.ShutdownGrace},
+		{"timeout-laporan", "TIMEOUT_LAPORAN", "batas waktu /laporan/tagging_pokin", &c.Timeouts.Laporan},
+		{"timeout-detail", "TIMEOUT_DETAIL", "batas waktu /tagging/getDetail/", &c.Timeouts.Detail},
+		{"timeout-detail-batch", "TIMEOUT_DETAIL_BATCH", "batas waktu /tagging/getDetailBatch", &c.Timeouts.DetailBatch},
+		{"timeout-health", "TIMEOUT_HEALTH", "batas waktu ping database di /health", &c.Timeouts.Health},
+		{"log-format", "LOG_FORMAT", "format log: json atau text", (*stringValue)(&c.Log.Format)},
+		{"log-level", "LOG_LEVEL", "level log: debug, info, warn, error", (*stringValue)(&c.Log.Level)},
+		{"cache-ttl", "CACHE_TTL", "umur cache response laporan, 0 untuk menonaktifkan", &c.Cache.TTL},
+		{"cache-max-bytes", "CACHE_MAX_BYTES", "ukuran maksimal cache response dalam byte", (*intValue)(&c.Cache.MaxBytes)},
+		{"report-instansi", "REPORT_INSTANSI", "baris pertama kop laporan PDF", (*stringValue)(&c.Report.Instansi)},
+		{"report-unit", "REPORT_UNIT", "baris kedua kop laporan PDF", (*stringValue)(&c.Report.Unit)},
+		{"report-alamat", "REPORT_ALAMAT", "alamat di kop laporan PDF", (*stringValue)(&c.Report.Alamat)},
+		{"report-kota", "REPORT_KOTA", "tempat tanda tangan laporan PDF", (*stringValue)(&c.Report.Kota)},
+		{"report-jabatan", "REPORT_JABATAN", "jabatan penandatangan laporan PDF", (*stringValue)(&c.Report.Jabatan)},
+		{"report-nama", "REPORT_NAMA", "nama penandatangan laporan PDF", (*stringValue)(&c.Report.Nama)},
+		{"report-nip", "REPORT_NIP", "NIP penandatangan laporan PDF", (*stringValue)(&c.Report.NIP)},
+	}
+}
+
+// LoadConfig membaca konfigurasi dari default, file, env lalu flag (args tanpa nama program)
+func LoadConfig(fs *flag.FlagSet, args []string) (Config, error) {
+	cfg := DefaultConfig()
+	settings := cfg.settings()
+
+	configPath := fs.String("config", os.Getenv("CONFIG_FILE"), "path file konfigurasi JSON (env CONFIG_FILE)")
+	for _, st := range settings {
+		fs.Var(st.value, st.flag, fmt.Sprintf("%s (env %s)", st.usage, st.env))
+	}
+	if err := fs.Parse(args); err != nil {
+		return Config{}, err
+	}
+
+	// flag yang di-set eksplisit diterapkan ulang setelah file dan env
+	explicit := make(map[string]string)
+	fs.Visit(func(f *flag.Flag) {
+		explicit[f.Name] = f.Value.String()
+	})
+
+	cfg = DefaultConfig()
+	if *configPath != "" {
+		data, err := os.ReadFile(*configPath)
+		if err != nil {
+			return Config{}, fmt.Errorf("baca config %s: %w", *configPath, err)
+		}
+		if err := json.Unmarshal(data, &cfg); err != nil {
+			return Config{}, fmt.Errorf("parse config %s: %w", *configPath, err)
+		}
+	}
+
+	for _, st := range settings {
+		if v, ok := os.LookupEnv(st.env); ok {
+			if err := st.value.Set(v); err != nil {
+				return Config{}, fmt.Errorf("env %s: %w", st.env, err)
+			}
+		}
+	}
+	for _, st := range settings {
+		if v, ok := explicit[st.flag]; ok {
+			st.value.Set(v)
+		}
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+func (c Config) Validate() error {
+	var errs []error
+
+	if c.Port < 1 || c.Port > 65535 {
+		errs = append(errs, fmt.Errorf("port %d di luar rentang 1-65535", c.Port))
+	}
+	if !c.Demo && c.DB.DSN == "" {
+		errs = append(errs, errors.New("PERENCANAAN_DB_URL env tidak terdefinisi"))
+	}
+	if c.DB.MaxOpenConns < 1 {
+		errs = append(errs, fmt.Errorf("db max_open_conns harus >= 1, dapat %d", c.DB.MaxOpenConns))
+	}
+	if c.DB.MaxIdleConns < 0 || c.DB.MaxIdleConns > c.DB.MaxOpenConns {
+		errs = append(errs, fmt.Errorf("db max_idle_conns (%d) harus di antara 0 dan max_open_conns (%d)", c.DB.MaxIdleConns, c.DB.MaxOpenConns))
+	}
+
+	for name, d := range map[string]Duration{
+		"db conn_max_idle_time":      c.DB.ConnMaxIdleTime,
+		"db conn_max_lifetime":       c.DB.ConnMaxLifetime,
+		"db ping_timeout":            c.DB.PingTimeout,
+		"server read_header_timeout": c.Server.ReadHeaderTimeout,
+		"server read_timeout":        c.Server.ReadTimeout,
+		"server write_timeout":       c.Server.WriteTimeout,
+		"server idle_timeout":        c.Server.IdleTimeout,
+		"server shutdown_timeout":    c.Server.ShutdownTimeout,
+		"server shutdown_grace":      c.Server.ShutdownGrace,
+		"timeouts laporan":           c.Timeouts.Laporan,
+		"timeouts detail":            c.Timeouts.Detail,
+		"timeouts detail_batch":      c.Timeouts.DetailBatch,
+		"cache ttl":                  c.Cache.TTL,
+	} {
+		if d < 0 {
+			errs = append(errs, fmt.Errorf("%s tidak boleh negatif", name))
+		}
+	}
+
+	if c.DB.PingTimeout <= 0 {
+		errs = append(errs, errors.New("db ping_timeout harus lebih dari 0"))
+	}
+	if c.Timeouts.Health <= 0 {
+		errs = append(errs, errors.New("timeouts health harus lebih dari 0"))
+	}
+	if c.Cache.TTL > 0 && c.Cache.MaxBytes < 1 {
+		errs = append(errs, fmt.Errorf("cache max_bytes harus >= 1 jika cache aktif, dapat %d", c.Cache.MaxBytes))
+	}
+	if c.DB.RetryInitialBackoff <= 0 || c.DB.RetryMaxBackoff < c.DB.RetryInitialBackoff {
+		errs = append(errs, fmt.Errorf("db retry_initial_backoff (%s) harus lebih dari 0 dan tidak melebihi retry_max_backoff (%s)", c.DB.RetryInitialBackoff, c.DB.RetryMaxBackoff))
+	}
+
+	// response 504 harus sempat terkirim sebelum koneksi diputus write timeout
+	if c.Server.WriteTimeout > 0 {
+		for name, d := range map[string]Duration{
+			"laporan":      c.Timeouts.Laporan,
+			"detail":       c.Timeouts.Detail,
+			"detail_batch": c.Timeouts.DetailBatch,
+		} {
+			if d == 0 || d >= c.Server.WriteTimeout {
+				errs = append(errs, fmt.Errorf("timeouts %s (%s) harus lebih kecil dari server write_timeout (%s)", name, d, c.Server.WriteTimeout))
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Redacted menyalin config tanpa password database dan token admin, aman untuk log dan endpoint admin
+func (c Config) Redacted() Config {
+	if c.AdminToken != "" {
+		c.AdminToken = "***"
+	}
+	if c.DB.DSN == "" {
+		return c
+	}
+	dsn, err := mysql.ParseDSN(c.DB.DSN)
+	if err != nil {
+		c.DB.DSN = "***"
+		return c
+	}
+	if dsn.Passwd != "" {
+		dsn.Passwd = "***"
+	}
+	c.DB.DSN = dsn.FormatDSN()
+	return c
+}
+
+func (c Config) Addr() string {
+	return ":" + strconv.Itoa(c.Port)
+}
+
+// Duration ditulis sebagai string time.ParseDuration ("30s", "5m") di JSON, env dan flag
+type Duration time.Duration
+
+func (d Duration) String() string { return time.Duration(d).String() }
+
+func (d *Duration) Set(s string) error {
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("durasi harus string, misal \"30s\": %w", err)
+	}
+	return d.Set(s)
+}
+
+type intValue int
+
+func (v *intValue) String() string { return strconv.Itoa(int(*v)) }
+
+func (v *intValue) Set(s string) error {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return err
+	}
+	*v = intValue(n)
+	return nil
+}
+
+type boolValue bool
+
+func (v *boolValue) String() string   { return strconv.FormatBool(bool(*v)) }
+func (v *boolValue) IsBoolFlag() bool { return true }
+
+func (v *boolValue) Set(s string) error {
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		return err
+	}
+	*v = boolValue(b)
+	return nil
+}
+
+type stringValue string
+
+func (v *stringValue) String() string { return string(*v) }
+
+func (v *stringValue) Set(s string) error {
+	*v = stringValue(s)
+	return nil
+}
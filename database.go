@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	_ "github.com/go-sql-driver/mysql"
+)
+
+// openDB menyiapkan pool tanpa menunggu database, koneksi pertama dibuat connectDB
+func openDB(cfg DBConfig) (*sql.DB, error) {
+	conn, err := sql.Open("mysql", cfg.DSN)
+	if err != nil {
+		return nil, fmt.Errorf("open db: %w", err)
+	}
+
+	conn.SetMaxOpenConns(cfg.MaxOpenConns)
+	conn.SetMaxIdleConns(cfg.MaxIdleConns)
+	conn.SetConnMaxIdleTime(time.Duration(cfg.ConnMaxIdleTime))
+	conn.SetConnMaxLifetime(time.Duration(cfg.ConnMaxLifetime))
+	return conn, nil
+}
+
+// connectDB mencoba PingContext sampai berhasil dengan exponential backoff,
+// lalu menandai service ready. Berhenti tanpa ready jika ctx dibatalkan (shutdown).
+func connectDB(ctx context.Context, conn *sql.DB, cfg DBConfig, ready *readiness) {
+	backoff := time.Duration(cfg.RetryInitialBackoff)
+	for attempt := 1; ; attempt++ {
+		pingCtx, cancel := context.WithTimeout(ctx, time.Duration(cfg.PingTimeout))
+		err := conn.PingContext(pingCtx)
+		cancel()
+		if err == nil {
+			break
+		}
+		if ctx.Err() != nil {
+			return
+		}
+
+		slog.Warn("koneksi database gagal, mencoba lagi", "attempt", attempt, "backoff", backoff.String(), "err", err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, time.Duration(cfg.RetryMaxBackoff))
+	}
+
+	stats := conn.Stats()
+	slog.Info("Berhasil terhubung ke database",
+		"max_open_connections", stats.MaxOpenConnections,
+		"open_connections", stats.OpenConnections,
+		"in_use", stats.InUse,
+		"idle", stats.Idle,
+	)
+
+	ready.set(true)
+}
+
+// closeDB menutup pool, conn nil di mode demo
+func closeDB(conn *sql.DB) {
+	if conn == nil {
+		return
+	}
+	if err := conn.Close(); err != nil {
+		slog.Error("close db error", "err", err)
+		return
+	}
+	slog.Info("koneksi database ditutup")
+}
+
+// readiness dipakai probe /ready, false sampai database pertama kali bisa di-ping
+// dan kembali false selamanya setelah shutdown dimulai
+type readiness struct {
+	ready    atomic.Bool
+	stopping atomic.Bool
+}
+
+func (rd *readiness) set(v bool) { rd.ready.Store(v) }
+
+// shutdown menandai tidak siap, tidak bisa dibatalkan oleh set(true) dari connectDB
+func (rd *readiness) shutdown() { rd.stopping.Store(true) }
+
+func (rd *readiness) handler(w http.ResponseWriter, r *http.Request) {
+	if rd.stopping.Load() {
+		writeError(w, http.StatusServiceUnavailable, errCodeNotReady, "server sedang berhenti")
+		return
+	}
+	if !rd.ready.Load() {
+		writeError(w, http.StatusServiceUnavailable, errCodeNotReady, "belum siap, menunggu koneksi database")
+		return
+	}
+	writeJSON(w, http.StatusOK, Response{
+		Status:  http.StatusOK,
+		Message: "ready",
+	})
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// batas jumlah kunci yang diingat versionTracker, lewat dari itu dimulai ulang
+const maxTrackedVersions = 10000
+
+// batas body request POST yang dibaca untuk kunci ETag
+const maxRequestBodyBytes = 1 << 20
+
+// versionTracker mengingat ETag terakhir per kunci request dan kapan ETag itu pertama muncul,
+// dipakai sebagai Last-Modified karena data perencanaan tidak punya kolom waktu ubah.
+type versionTracker struct {
+	mu       sync.Mutex
+	versions map[string]trackedVersion
+}
+
+type trackedVersion struct {
+	etag       string
+	modifiedAt time.Time
+}
+
+func newVersionTracker() *versionTracker {
+	return &versionTracker{versions: make(map[string]trackedVersion)}
+}
+
+func (t *versionTracker) lastModified(key, etag string) time.Time {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if v, ok := t.versions[key]; ok && v.etag == etag {
+		return v.modifiedAt
+	}
+	if len(t.versions) >= maxTrackedVersions {
+		clear(t.versions)
+	}
+	// presisi header HTTP hanya detik
+	now := time.Now().UTC().Truncate(time.Second)
+	t.versions[key] = trackedVersion{etag: etag, modifiedAt: now}
+	return now
+}
+
+// conditional menambahkan ETag (hash isi body) dan Last-Modified ke response 200,
+// lalu membalas 304 tanpa body jika If-None-Match / If-Modified-Since cocok (hanya GET/HEAD).
+// Endpoint batch (POST) juga diberi ETag, kuncinya ikut body request, tapi If-None-Match yang cocok
+// dibalas 412 sesuai RFC 9110 dan If-Modified-Since diabaikan.
+// Response dengan Cache-Control: no-store (laporan sebagian) dilewatkan apa adanya,
+// begitu juga export csv yang di-stream karena body tidak boleh ditahan untuk dihitung hash-nya.
+func (t *versionTracker) conditional(route string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// body POST dibatasi sebelum apa pun, termasuk export csv yang tidak diberi ETag
+		var bodySum string
+		if r.Body != nil && r.Method == http.MethodPost {
+			body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestBodyBytes))
+			if err != nil {
+				var tooLarge *http.MaxBytesError
+				if errors.As(err, &tooLarge) {
+					writeError(w, http.StatusRequestEntityTooLarge, errCodeBodyTooLarge,
+						fmt.Sprintf("body request maksimal %d byte", tooLarge.Limit))
+					return
+				}
+				writeError(w, http.StatusBadRequest, errCodeInvalidBody, "body request gagal dibaca")
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			sum := sha256.Sum256(body)
+			bodySum = "#" + hex.EncodeToString(sum[:8])
+		}
+
+		if r.URL.Query().Get("format") == formatCSV {
+			next(w, r)
+			return
+		}
+
+		key := cacheKey(r) + bodySum
+		rec := &bufferedWriter{ResponseWriter: w}
+		next(rec, r)
+
+		if rec.status != http.StatusOK || strings.Contains(w.Header().Get("Cache-Control"), "no-store") {
+			rec.flush()
+			return
+		}
+
+		sum := sha256.Sum256(rec.buf.Bytes())
+		etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+		modified := t.lastModified(route+" "+key, etag)
+
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Last-Modified", modified.Format(http.TimeFormat))
+		// boleh disimpan client tapi harus divalidasi ulang tiap dipakai
+		if w.Header().Get("Cache-Control") == "" {
+			w.Header().Set("Cache-Control", "no-cache")
+		}
+
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			if inm := r.Header.Get("If-None-Match"); inm != "" && matchETag(inm, etag) {
+				writeError(w, http.StatusPreconditionFailed, errCodePreconditionFailed, "data tidak berubah sejak ETag di If-None-Match")
+				return
+			}
+			rec.flush()
+			return
+		}
+
+		if notModified(r, etag, modified) {
+			h := w.Header()
+			h.Del("Content-Type")
+			h.Del("Content-Length")
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		rec.flush()
+	}
+}
+
+// If-None-Match didahulukan, If-Modified-Since hanya dipakai jika If-None-Match tidak dikirim
+func notModified(r *http.Request, etag string, modified time.Time) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		return matchETag(inm, etag)
+	}
+	if ims := r.Header.Get("If-Modified-Since"); ims != "" {
+		t, err := http.ParseTime(ims)
+		return err == nil && !modified.After(t)
+	}
+	return false
+}
+
+// matchETag cek daftar If-None-Match, perbandingan lemah (W/ diabaikan)
+func matchETag(inm, etag string) bool {
+	for _, candidate := range strings.Split(inm, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// bufferedWriter menahan status dan body sampai ETag selesai dihitung
+type bufferedWriter struct {
+	http.ResponseWriter
+	status int
+	buf    bytes.Buffer
+}
+
+func (bw *bufferedWriter) WriteHeader(code int) {
+	if bw.status == 0 {
+		bw.status = code
+	}
+}
+
+func (bw *bufferedWriter) Write(b []byte) (int, error) {
+	if bw.status == 0 {
+		bw.status = http.StatusOK
+	}
+	return bw.buf.Write(b)
+}
+
+func (bw *bufferedWriter) flush() {
+	if bw.status == 0 {
+		// handler tidak menulis apa pun (client putus)
+		return
+	}
+	bw.ResponseWriter.WriteHeader(bw.status)
+	bw.ResponseWriter.Write(bw.buf.Bytes())
+}
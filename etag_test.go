@@ -0,0 +1,64 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestConditional(t *testing.T) {
+	echo := func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		writeJSON(w, http.StatusOK, Response{Status: http.StatusOK, Message: "ok", Data: string(body)})
+	}
+	h := newVersionTracker().conditional("/x", echo)
+
+	serve := func(method, target, body, inm string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, target, strings.NewReader(body))
+		if inm != "" {
+			r.Header.Set("If-None-Match", inm)
+		}
+		rec := httptest.NewRecorder()
+		h(rec, r)
+		return rec
+	}
+
+	getETag := serve(http.MethodGet, "/x", "", "").Header().Get("ETag")
+	postETag := serve(http.MethodPost, "/x", `{"a":1}`, "").Header().Get("ETag")
+	if getETag == "" || postETag == "" {
+		t.Fatalf("ETag kosong: GET %q, POST %q", getETag, postETag)
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		inm    string
+		status int
+	}{
+		{name: "GET tanpa If-None-Match", method: http.MethodGet, target: "/x", status: http.StatusOK},
+		{name: "GET ETag cocok", method: http.MethodGet, target: "/x", inm: getETag, status: http.StatusNotModified},
+		{name: "GET ETag lemah cocok", method: http.MethodGet, target: "/x", inm: "W/" + getETag, status: http.StatusNotModified},
+		{name: "GET ETag lain", method: http.MethodGet, target: "/x", inm: `"lain"`, status: http.StatusOK},
+		{name: "POST ETag cocok", method: http.MethodPost, target: "/x", body: `{"a":1}`, inm: postETag, status: http.StatusPreconditionFailed},
+		{name: "POST body lain", method: http.MethodPost, target: "/x", body: `{"a":2}`, inm: postETag, status: http.StatusOK},
+		{name: "POST body terlalu besar", method: http.MethodPost, target: "/x",
+			body: strings.Repeat(" ", maxRequestBodyBytes+1), status: http.StatusRequestEntityTooLarge},
+		{name: "POST csv body terlalu besar", method: http.MethodPost, target: "/x?format=csv",
+			body: strings.Repeat(" ", maxRequestBodyBytes+1), status: http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(tt.method, tt.target, tt.body, tt.inm)
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d\n%s", rec.Code, tt.status, rec.Body)
+			}
+			if tt.status == http.StatusNotModified && rec.Body.Len() > 0 {
+				t.Errorf("304 tidak boleh ada body: %s", rec.Body)
+			}
+		})
+	}
+}
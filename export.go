@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Helper bersama untuk export laporan (xlsx, csv, pdf).
+
+// laporanOpd pokin laporan yang dikelompokkan per perangkat daerah, urut kemunculan pertama.
+// Pagu dijumlah per rekin unik seperti summary, pokin yang di tagging ke lebih dari satu
+// program unggulan muncul beberapa kali tapi pagu rekinnya hanya dihitung sekali.
+type laporanOpd struct {
+	KodeOpd string
+	NamaOpd string
+	Pokins  []Pokin
+	Pagu    int64
+}
+
+func groupByOpd(pokins []Pokin) []laporanOpd {
+	var groups []laporanOpd
+	index := make(map[string]int)
+	for _, p := range pokins {
+		i, ok := index[p.KodeOpd]
+		if !ok {
+			groups = append(groups, laporanOpd{KodeOpd: p.KodeOpd, NamaOpd: p.NamaOpd})
+			i = len(groups) - 1
+			index[p.KodeOpd] = i
+		}
+		groups[i].Pokins = append(groups[i].Pokins, p)
+	}
+	for i := range groups {
+		groups[i].Pagu = totalPagu(groups[i].Pokins)
+	}
+	return groups
+}
+
+// totalPagu jumlah pagu rekin unik (per IdRekin) di bawah pokins
+func totalPagu(pokins []Pokin) int64 {
+	var total int64
+	seen := make(map[string]struct{})
+	for _, p := range pokins {
+		for _, pel := range p.Pelaksanas {
+			for _, rk := range pel.RencanaKinerjas {
+				if _, ok := seen[rk.IdRekin]; ok {
+					continue
+				}
+				seen[rk.IdRekin] = struct{}{}
+				total += int64(rk.Pagu)
+			}
+		}
+	}
+	return total
+}
+
+// indikatorColumns indikator, target dan satuan pokin, tiap indikator dipisah sep
+func indikatorColumns(p Pokin, sep string) (indikator, target, satuan string) {
+	var inds, targets, satuans []string
+	for _, ind := range p.Indikator {
+		inds = append(inds, ind.Indikator)
+		var tgts []string
+		var sat string
+		for _, t := range ind.Target {
+			tgts = append(tgts, t.Target)
+			if sat == "" {
+				sat = t.Satuan
+			}
+		}
+		targets = append(targets, strings.Join(tgts, ", "))
+		satuans = append(satuans, sat)
+	}
+	return strings.Join(inds, sep), strings.Join(targets, sep), strings.Join(satuans, sep)
+}
+
+// gabung kode dan nama, salah satu boleh kosong
+func kodeNama(kode, nama string) string {
+	kode, nama = strings.TrimSpace(kode), strings.TrimSpace(nama)
+	switch {
+	case kode == "" || kode == "-":
+		return nama
+	case nama == "" || nama == "-":
+		return kode
+	}
+	return kode + " " + nama
+}
+
+var unsafeFilename = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// safeFilename mengganti karakter selain huruf, angka, titik, minus dan underscore dengan _
+func safeFilename(name string) string {
+	return strings.Trim(unsafeFilename.ReplaceAllString(name, "_"), "_")
+}
+
+// exportFilename nama file download, misal laporan-tagging-Program_Unggulan_Bupati-2025.xlsx
+func exportFilename(namaTagging string, tahun Tahun, ext string) string {
+	return fmt.Sprintf("laporan-tagging-%s-%d.%s", safeFilename(namaTagging), tahun, ext)
+}
+
+func attachment(w http.ResponseWriter, contentType, filename string) {
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+}
+
+// nilai ?format=
+const (
+	formatJSON = "json"
+	formatXLSX = "xlsx"
+	formatCSV  = "csv"
+	formatPDF  = "pdf"
+)
+
+// parseFormat membaca ?format=, kosong = json
+func parseFormat(param string, allowed ...string) (string, error) {
+	if param == "" {
+		return formatJSON, nil
+	}
+	if param == formatJSON || slices.Contains(allowed, param) {
+		return param, nil
+	}
+	return "", fmt.Errorf("pilih: %s", strings.Join(append([]string{formatJSON}, allowed...), ", "))
+}
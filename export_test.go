@@ -0,0 +1,37 @@
+package main
+
+import "testing"
+
+func TestGroupByOpdPaguMatchesSummary(t *testing.T) {
+	rekin := func(id string, pagu Pagu) RencanaKinerjaAsn {
+		return RencanaKinerjaAsn{IdRekin: id, Pagu: pagu}
+	}
+	pokin := func(kodeProgram string) Pokin {
+		return Pokin{
+			IdPohon: 103, KodeOpd: "5.01", NamaOpd: "Bappeda", KodeProgramUnggulan: kodeProgram,
+			Pelaksanas: []PelaksanaPokin{{NIPPelaksana: "1", RencanaKinerjas: []RencanaKinerjaAsn{
+				rekin("REKIN-1", 100), rekin("REKIN-2", 50),
+			}}},
+		}
+	}
+	// pokin yang sama di tagging ke dua program unggulan
+	pokins := []Pokin{pokin("PU-01"), pokin("PU-02"), {
+		IdPohon: 203, KodeOpd: "1.02", NamaOpd: "Dinkes",
+		Pelaksanas: []PelaksanaPokin{{NIPPelaksana: "2", RencanaKinerjas: []RencanaKinerjaAsn{rekin("REKIN-3", 7)}}},
+	}}
+
+	groups := groupByOpd(pokins)
+	if len(groups) != 2 || groups[0].Pagu != 150 || groups[1].Pagu != 7 {
+		t.Fatalf("pagu per OPD = %+v, want 5.01=150 dan 1.02=7", groups)
+	}
+	summary := summarize("Program Unggulan Bupati", 2025, pokins)
+	if got := totalPagu(pokins); got != summary.Total.TotalPagu {
+		t.Errorf("total pagu export = %d, summary = %d", got, summary.Total.TotalPagu)
+	}
+	for i, g := range summary.PerOpd {
+		// PerOpd urut kode: 1.02 lalu 5.01
+		if want := groups[len(groups)-1-i]; g.TotalPagu != want.Pagu {
+			t.Errorf("pagu OPD %s: summary %d, export %d", g.Kode, g.TotalPagu, want.Pagu)
+		}
+	}
+}
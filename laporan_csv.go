@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/csv"
+	"net/http"
+	"strconv"
+)
+
+// baris di-flush ke client tiap sekian rekin supaya file besar tidak tertahan di buffer
+const csvFlushRows = 500
+
+// kolom csv, nama kolom mengikuti field JSON
+var pokinCSVHeader = []string{
+	"tahun", "kode_opd", "nama_opd",
+	"kode_program_unggulan", "nama_program_unggulan", "rencana_implementasi",
+	"id_pohon", "nama_pohon", "jenis_pohon", "status", "keterangan_tagging",
+	"indikator", "target", "satuan",
+	"nama_pelaksana", "nip_pelaksana",
+	"id_rekin", "rencana_kinerja",
+	"kode_bidang_urusan", "nama_bidang_urusan", "kode_program", "nama_program",
+	"kode_subkegiatan", "nama_subkegiatan", "pagu",
+	"tw_1", "tw_2", "tw_3", "tw_4", "keterangan",
+}
+
+// writePokinsCSV menulis pokin sebagai csv, satu baris per rekin dengan kolom pokin dan
+// pelaksana diulang; pokin tanpa rekin tetap satu baris. Yang di-stream hanya output:
+// semua pokin sudah dimuat di memori (sama dengan JSON), hanya file csv-nya yang tidak
+// dibangun utuh, baris ditulis dan di-flush bertahap supaya byte pertama cepat sampai.
+// Error di tengah jalan hanya bisa dicatat di log.
+// Karena status 200 sudah terkirim sebelum tahu berhasil, response ditandai no-store supaya
+// file yang terpotong tidak pernah disimpan cache.
+func writePokinsCSV(w http.ResponseWriter, r *http.Request, filename string, pokins []Pokin) {
+	attachment(w, "text/csv; charset=utf-8", filename)
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+
+	rc := http.NewResponseController(w)
+	cw := csv.NewWriter(w)
+	cw.Write(pokinCSVHeader)
+
+	rows := 0
+	write := func(record []string) bool {
+		cw.Write(record)
+		rows++
+		if rows%csvFlushRows == 0 {
+			cw.Flush()
+			rc.Flush()
+		}
+		// client putus atau deadline habis, sisa baris tidak perlu ditulis
+		return cw.Error() == nil && r.Context().Err() == nil
+	}
+
+	for _, p := range pokins {
+		if !writePokinCSVRows(p, write) {
+			break
+		}
+	}
+	cw.Flush()
+
+	if err := cw.Error(); err != nil {
+		loggerFrom(r.Context()).Error("tulis csv gagal", "file", filename, "rows", rows, "err", err)
+		return
+	}
+	if err := r.Context().Err(); err != nil {
+		loggerFrom(r.Context()).Warn("tulis csv dihentikan", "file", filename, "rows", rows, "err", err)
+	}
+}
+
+func writePokinCSVRows(p Pokin, write func([]string) bool) bool {
+	indikator, target, satuan := indikatorColumns(p, "; ")
+	pokinCols := []string{
+		strconv.Itoa(int(p.Tahun)), p.KodeOpd, p.NamaOpd,
+		p.KodeProgramUnggulan, p.NamaProgramUnggulan, p.RencanaImplementasi,
+		strconv.Itoa(p.IdPohon), p.NamaPohon, string(p.JenisPohon), p.Status, p.KeteranganTagging,
+		indikator, target, satuan,
+	}
+	record := func(cols ...string) []string {
+		return append(append(make([]string, 0, len(pokinCSVHeader)), pokinCols...), cols...)
+	}
+
+	written := false
+	for _, pel := range p.Pelaksanas {
+		for _, rk := range pel.RencanaKinerjas {
+			written = true
+			tw := rk.TahapanPelaksanaan
+			if !write(record(
+				pel.NamaPelaksana, pel.NIPPelaksana,
+				rk.IdRekin, rk.RencanaKinerja,
+				rk.KodeBidangUrusan, rk.NamaBidangUrusan, rk.KodeProgram, rk.NamaProgram,
+				rk.KodeSubkegiatan, rk.NamaSubkegiatan, strconv.Itoa(int(rk.Pagu)),
+				strconv.Itoa(tw.Tw1), strconv.Itoa(tw.Tw2), strconv.Itoa(tw.Tw3), strconv.Itoa(tw.Tw4), rk.Catatan,
+			)) {
+				return false
+			}
+		}
+	}
+	if !written {
+		return write(record(make([]string, len(pokinCSVHeader)-len(pokinCols))...))
+	}
+	return true
+}
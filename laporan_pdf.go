@@ -0,0 +1,380 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ukuran halaman dan huruf laporan PDF, dalam point
+const (
+	pdfMargin      = 36.0
+	pdfFooterSpace = 40.0
+	pdfFontSize    = 7.5
+	pdfLeading     = pdfFontSize * 1.25
+	pdfCellPad     = 3.0
+	// tinggi blok tanda tangan, dipindah ke halaman baru jika tidak muat
+	pdfSignatureHeight = 120.0
+)
+
+// kolom tabel laporan PDF, jumlah lebar = lebar A4 landscape dikurangi margin
+var laporanPDFColumns = []struct {
+	title string
+	width float64
+}{
+	{"No", 24},
+	{"Pohon Kinerja", 150},
+	{"Indikator / Target", 120},
+	{"Pelaksana", 100},
+	{"Rencana Kinerja", 140},
+	{"Sub Kegiatan", 140},
+	{"Pagu (Rp)", 95},
+}
+
+// kolom 0-2 berisi data pokin dan digabung untuk semua rekin di bawahnya
+const pdfPokinCols = 3
+
+// writeLaporanPDF membalas laporan sebagai file pdf, dirender ke buffer dulu seperti xlsx
+func writeLaporanPDF(w http.ResponseWriter, r *http.Request, report TagPokin, cfg ReportConfig) {
+	var buf bytes.Buffer
+	if err := renderLaporanPDF(&buf, report, cfg, time.Now()); err != nil {
+		loggerFrom(r.Context()).Error("render pdf gagal", "nama_tagging", report.NamaTagging, "tahun", report.Tahun, "err", err)
+		writeError(w, http.StatusInternalServerError, errCodeInternal, "laporan pdf gagal dibuat")
+		return
+	}
+
+	attachment(w, pdfContentType, exportFilename(report.NamaTagging, report.Tahun, formatPDF))
+	w.Header().Set("Content-Length", strconv.Itoa(buf.Len()))
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf.Bytes())
+}
+
+// laporanPDF menyimpan posisi tulis saat menyusun halaman
+type laporanPDF struct {
+	doc    *pdfDoc
+	y      float64
+	bottom float64
+	// ada baris data di halaman ini (selain header tabel)
+	hasRows bool
+}
+
+// renderLaporanPDF menulis laporan: kop di halaman pertama, tabel per perangkat daerah
+// dengan header diulang tiap halaman, jumlah pagu per perangkat daerah, total pagu,
+// blok tanda tangan dan nomor halaman
+func renderLaporanPDF(w io.Writer, report TagPokin, cfg ReportConfig, tanggal time.Time) error {
+	lp := &laporanPDF{doc: newPDF(pdfA4Long, pdfA4Short)}
+	lp.bottom = lp.doc.height - pdfFooterSpace
+	lp.doc.addPage()
+	lp.kop(report, cfg)
+	lp.tableHeader()
+
+	no := 0
+	for _, opd := range groupByOpd(report.PohonKinerjas) {
+		lp.fullRow(kodeNama(opd.KodeOpd, opd.NamaOpd), "", pdfHelveticaBold, 0.93)
+		for _, p := range opd.Pokins {
+			no++
+			lp.pokin(no, p)
+		}
+		lp.fullRow("Jumlah Pagu "+opd.NamaOpd, formatRupiah(opd.Pagu), pdfHelveticaBold, 0.93)
+	}
+	lp.fullRow("TOTAL PAGU", formatRupiah(totalPagu(report.PohonKinerjas)), pdfHelveticaBold, 0.85)
+
+	lp.signature(cfg, tanggal)
+	lp.footer(report)
+	return lp.doc.write(w)
+}
+
+func (lp *laporanPDF) kop(report TagPokin, cfg ReportConfig) {
+	d := lp.doc
+	center := d.width / 2
+	y := pdfMargin + 14
+	d.textCenter(center, y, pdfHelveticaBold, 13, strings.ToUpper(cfg.Instansi))
+	if cfg.Unit != "" {
+		y += 15
+		d.textCenter(center, y, pdfHelveticaBold, 12, strings.ToUpper(cfg.Unit))
+	}
+	if cfg.Alamat != "" {
+		y += 12
+		d.textCenter(center, y, pdfHelvetica, 8.5, cfg.Alamat)
+	}
+	// garis kop ganda
+	y += 8
+	d.line(pdfMargin, y, d.width-pdfMargin, y, 1.5)
+	d.line(pdfMargin, y+2.5, d.width-pdfMargin, y+2.5, 0.5)
+
+	y += 22
+	d.textCenter(center, y, pdfHelveticaBold, 12, "LAPORAN TAGGING POHON KINERJA")
+	y += 14
+	d.textCenter(center, y, pdfHelvetica, 10, fmt.Sprintf("%s Tahun %d", report.NamaTagging, report.Tahun))
+	lp.y = y + 14
+}
+
+func (lp *laporanPDF) newPage() {
+	lp.doc.addPage()
+	lp.y = pdfMargin
+	lp.hasRows = false
+	lp.tableHeader()
+}
+
+func (lp *laporanPDF) tableHeader() {
+	x := pdfMargin
+	h := pdfLeading + 2*pdfCellPad
+	for _, c := range laporanPDFColumns {
+		lp.doc.rect(x, lp.y, c.width, h, 0.85)
+		lp.doc.textCenter(x+c.width/2, lp.y+pdfCellPad+pdfFontSize, pdfHelveticaBold, pdfFontSize, c.title)
+		x += c.width
+	}
+	lp.y += h
+}
+
+// fullRow baris selebar tabel: label di kolom gabungan, value (opsional) di kolom pagu
+func (lp *laporanPDF) fullRow(label, value string, font pdfFont, fill float64) {
+	h := pdfLeading + 2*pdfCellPad
+	if lp.y+h > lp.bottom {
+		lp.newPage()
+	}
+	paguWidth := laporanPDFColumns[len(laporanPDFColumns)-1].width
+	labelWidth := tableWidth() - paguWidth
+	baseline := lp.y + pdfCellPad + pdfFontSize
+
+	lp.doc.rect(pdfMargin, lp.y, labelWidth, h, fill)
+	lp.doc.text(pdfMargin+pdfCellPad, baseline, font, pdfFontSize, label)
+	lp.doc.rect(pdfMargin+labelWidth, lp.y, paguWidth, h, fill)
+	if value != "" {
+		lp.doc.textRight(pdfMargin+labelWidth+paguWidth-pdfCellPad, baseline, font, pdfFontSize, value)
+	}
+	lp.y += h
+	lp.hasRows = true
+}
+
+// pdfRekinRow satu baris rekin, kolom 3 dst sudah dipecah per baris teks
+type pdfRekinRow struct {
+	cells  [][]string
+	height float64
+}
+
+func (lp *laporanPDF) pokin(no int, p Pokin) {
+	pokinCells := [][]string{
+		{strconv.Itoa(no)},
+		wrapCell(p.NamaPohon+"\n("+string(p.JenisPohon)+")", 1),
+		wrapCell(indikatorTarget(p), 2),
+	}
+	pokinHeight := cellHeight(pokinCells...)
+
+	var rows []pdfRekinRow
+	for _, pel := range p.Pelaksanas {
+		for i, rk := range pel.RencanaKinerjas {
+			pelaksana := ""
+			if i == 0 {
+				pelaksana = pel.NamaPelaksana + "\nNIP " + pel.NIPPelaksana
+			}
+			cells := [][]string{
+				wrapCell(pelaksana, 3),
+				wrapCell(rk.RencanaKinerja, 4),
+				wrapCell(kodeNama(rk.KodeSubkegiatan, rk.NamaSubkegiatan), 5),
+				{formatRupiah(int64(rk.Pagu))},
+			}
+			rows = append(rows, pdfRekinRow{cells: cells, height: cellHeight(cells...)})
+		}
+	}
+	if len(rows) == 0 {
+		rows = append(rows, pdfRekinRow{cells: [][]string{nil, nil, nil, nil}, height: cellHeight()})
+	}
+
+	// pokin dimulai di halaman baru jika baris pertamanya tidak muat
+	if lp.hasRows && lp.y+max(min(pokinHeight, lp.bottom-pdfMargin), rows[0].height) > lp.bottom {
+		lp.newPage()
+	}
+
+	// baris rekin dibagi per halaman, kolom pokin diulang di tiap potongan
+	for i := 0; i < len(rows); {
+		start := i
+		blockHeight := 0.0
+		for i < len(rows) && lp.y+blockHeight+rows[i].height <= lp.bottom {
+			blockHeight += rows[i].height
+			i++
+		}
+		if i == start {
+			if lp.hasRows {
+				lp.newPage()
+				continue
+			}
+			// satu baris lebih tinggi dari halaman, tetap ditulis (terpotong)
+			blockHeight += rows[i].height
+			i++
+		}
+
+		// baris terakhir diperpanjang jika kolom pokin lebih tinggi
+		lastExtra := 0.0
+		if i == len(rows) && pokinHeight > blockHeight {
+			lastExtra = min(pokinHeight, lp.bottom-lp.y) - blockHeight
+			lastExtra = max(lastExtra, 0)
+			blockHeight += lastExtra
+		}
+
+		x := pdfMargin
+		for col, lines := range pokinCells {
+			lp.cell(x, lp.y, laporanPDFColumns[col].width, blockHeight, lines, false)
+			x += laporanPDFColumns[col].width
+		}
+		y := lp.y
+		for j := start; j < i; j++ {
+			h := rows[j].height
+			if j == i-1 {
+				h += lastExtra
+			}
+			cx := x
+			for k, lines := range rows[j].cells {
+				col := pdfPokinCols + k
+				lp.cell(cx, y, laporanPDFColumns[col].width, h, lines, col == len(laporanPDFColumns)-1)
+				cx += laporanPDFColumns[col].width
+			}
+			y += h
+		}
+		lp.y += blockHeight
+		lp.hasRows = true
+	}
+}
+
+// cell menggambar kotak dan teksnya, baris yang melewati tinggi kotak tidak ditulis
+func (lp *laporanPDF) cell(x, y, w, h float64, lines []string, alignRight bool) {
+	lp.doc.rect(x, y, w, h, -1)
+	baseline := y + pdfCellPad + pdfFontSize
+	for _, line := range lines {
+		if baseline > y+h {
+			break
+		}
+		if alignRight {
+			lp.doc.textRight(x+w-pdfCellPad, baseline, pdfHelvetica, pdfFontSize, line)
+		} else {
+			lp.doc.text(x+pdfCellPad, baseline, pdfHelvetica, pdfFontSize, line)
+		}
+		baseline += pdfLeading
+	}
+}
+
+func (lp *laporanPDF) signature(cfg ReportConfig, tanggal time.Time) {
+	if lp.y+pdfSignatureHeight > lp.bottom {
+		lp.doc.addPage()
+		lp.y = pdfMargin
+	}
+
+	const width = 240.0
+	d := lp.doc
+	center := d.width - pdfMargin - width/2
+	y := lp.y + 28
+
+	tempat := tanggalIndonesia(tanggal)
+	if cfg.Kota != "" {
+		tempat = cfg.Kota + ", " + tempat
+	}
+	d.textCenter(center, y, pdfHelvetica, 9, tempat)
+	for _, line := range pdfHelvetica.wrap(cfg.Jabatan, 9, width) {
+		y += 11
+		d.textCenter(center, y, pdfHelvetica, 9, line)
+	}
+
+	// ruang tanda tangan
+	y += 56
+	nama := cfg.Nama
+	if nama == "" {
+		nama = "(..............................................)"
+	}
+	d.textCenter(center, y, pdfHelveticaBold, 9, nama)
+	if cfg.Nama != "" {
+		nw := pdfHelveticaBold.width(nama, 9)
+		d.line(center-nw/2, y+1.5, center+nw/2, y+1.5, 0.5)
+	}
+	y += 11
+	nip := cfg.NIP
+	if nip == "" {
+		nip = "...................................."
+	}
+	d.textCenter(center, y, pdfHelvetica, 9, "NIP. "+nip)
+	lp.y = y
+}
+
+// footer menulis keterangan laporan dan nomor halaman di semua halaman
+func (lp *laporanPDF) footer(report TagPokin) {
+	d := lp.doc
+	y := d.height - pdfMargin + 12
+	total := d.pageCount()
+	for i := 0; i < total; i++ {
+		d.usePage(i)
+		d.text(pdfMargin, y, pdfHelvetica, 7, fmt.Sprintf("Laporan Tagging %s Tahun %d", report.NamaTagging, report.Tahun))
+		d.textRight(d.width-pdfMargin, y, pdfHelvetica, 7, fmt.Sprintf("Halaman %d dari %d", i+1, total))
+	}
+}
+
+func tableWidth() float64 {
+	total := 0.0
+	for _, c := range laporanPDFColumns {
+		total += c.width
+	}
+	return total
+}
+
+func wrapCell(s string, col int) []string {
+	if s == "" {
+		return nil
+	}
+	return pdfHelvetica.wrap(s, pdfFontSize, laporanPDFColumns[col].width-2*pdfCellPad)
+}
+
+// cellHeight tinggi baris mengikuti sel dengan teks terbanyak, minimal satu baris
+func cellHeight(cells ...[]string) float64 {
+	lines := 1
+	for _, c := range cells {
+		lines = max(lines, len(c))
+	}
+	return float64(lines)*pdfLeading + 2*pdfCellPad
+}
+
+// indikatorTarget satu baris per indikator: "nama indikator: target satuan"
+func indikatorTarget(p Pokin) string {
+	lines := make([]string, 0, len(p.Indikator))
+	for _, ind := range p.Indikator {
+		line := ind.Indikator
+		var targets []string
+		for _, t := range ind.Target {
+			targets = append(targets, strings.TrimSpace(t.Target+" "+t.Satuan))
+		}
+		if t := strings.Join(targets, ", "); t != "" {
+			line += ": " + t
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// formatRupiah 1234567 -> Rp 1.234.567
+func formatRupiah(v int64) string {
+	sign := ""
+	if v < 0 {
+		sign = "-"
+		v = -v
+	}
+	digits := strconv.FormatInt(v, 10)
+	var b strings.Builder
+	for i, c := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteByte('.')
+		}
+		b.WriteRune(c)
+	}
+	return sign + "Rp " + b.String()
+}
+
+var namaBulan = [...]string{
+	"Januari", "Februari", "Maret", "April", "Mei", "Juni",
+	"Juli", "Agustus", "September", "Oktober", "November", "Desember",
+}
+
+// tanggalIndonesia 2025-03-07 -> 7 Maret 2025
+func tanggalIndonesia(t time.Time) string {
+	return fmt.Sprintf("%d %s %d", t.Day(), namaBulan[t.Month()-1], t.Year())
+}
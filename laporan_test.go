@@ -0,0 +1,220 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const testNamaTagging = "Program Unggulan Bupati"
+
+// countingDB database/sql driver palsu untuk mysqlRepository: menghitung statement yang
+// benar-benar dieksekusi dan menjawab tiap query laporan dengan n pokin, masing-masing
+// dengan satu rekin, indikator, rencana aksi dan bidang urusan.
+type countingDB struct {
+	pokins int
+
+	mu         sync.Mutex
+	statements []string
+}
+
+func (db *countingDB) Connect(context.Context) (driver.Conn, error) { return countingConn{db}, nil }
+func (db *countingDB) Driver() driver.Driver                        { return nil }
+
+func (db *countingDB) count() int {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	return len(db.statements)
+}
+
+type countingConn struct{ db *countingDB }
+
+func (c countingConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("countingDB: prepare tidak didukung")
+}
+func (c countingConn) Close() error { return nil }
+func (c countingConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("countingDB: transaksi tidak didukung")
+}
+
+// QueryContext satu pemanggilan = satu statement di MySQL
+func (c countingConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	name, rows, err := c.db.answer(query, args)
+	if err != nil {
+		return nil, err
+	}
+	c.db.mu.Lock()
+	c.db.statements = append(c.db.statements, name)
+	c.db.mu.Unlock()
+	return &countingRows{values: rows}, nil
+}
+
+const testKodeOpd = "1.02.0.00.0.00.01.0000"
+
+// answer mengenali query dari potongan SQL-nya, baris dibentuk dari argumen query
+func (db *countingDB) answer(query string, args []driver.NamedValue) (string, [][]driver.Value, error) {
+	var rows [][]driver.Value
+	each := func(row func(arg driver.Value) []driver.Value) {
+		for _, a := range args {
+			rows = append(rows, row(a.Value))
+		}
+	}
+	// kode program berulang antar rekin seperti data asli, tidak satu program per rekin
+	program := func(id driver.Value) string { return fmt.Sprintf("1.02.%02d", id.(int64)%5) }
+
+	switch {
+	case strings.Contains(query, "SELECT COUNT(*)"):
+		return "count pokin", [][]driver.Value{{int64(db.pokins)}}, nil
+	case strings.Contains(query, "opd.nama_opd"):
+		for i := range db.pokins {
+			id := int64(10000 + i)
+			rows = append(rows, []driver.Value{id, fmt.Sprintf("Pokin %d", i), int64(2025), "Operational", testKodeOpd,
+				"Dinas Kesehatan", "", "", int64(1), "PU-01", "Program Unggulan", "", ""})
+		}
+		return "pokin", rows, nil
+	case strings.Contains(query, "SUM(rinbel.anggaran)"):
+		each(func(id driver.Value) []driver.Value { return []driver.Value{fmt.Sprint("REKIN-", id), int64(1000000)} })
+		return "pagu", rows, nil
+	case strings.Contains(query, "JOIN tb_pegawai"):
+		each(func(id driver.Value) []driver.Value {
+			return []driver.Value{id, fmt.Sprint("REKIN-", id), "Rekin", "PEG-004", "Pelaksana", "197804042003122004",
+				"1.02.02.2.02.0017", "Subkegiatan", program(id), "Program", "", testKodeOpd}
+		})
+		return "rekin", rows, nil
+	case strings.Contains(query, "FROM tb_indikator_matrix"):
+		for i := 0; i+2 < len(args); i += 3 {
+			rows = append(rows, []driver.Value{args[i].Value, args[i+1].Value, args[i+2].Value, "Indikator Program"})
+		}
+		return "indikator program", rows, nil
+	case strings.Contains(query, "FROM tb_pelaksanaan_rencana_aksi"):
+		each(func(id driver.Value) []driver.Value { return []driver.Value{id, int64(3), int64(100)} })
+		return "tahapan pelaksanaan", rows, nil
+	case strings.Contains(query, "FROM tb_indikator ind"):
+		each(func(id driver.Value) []driver.Value {
+			return []driver.Value{fmt.Sprint("IND-", id), id, "Indikator", fmt.Sprint("TRGT-", id), "100", "persen", int64(2025)}
+		})
+		return "indikator pokin", rows, nil
+	case strings.Contains(query, "tree.root_id"):
+		// pokin id dipakai 3 kali di query hierarki
+		args = args[:len(args)/3]
+		each(func(id driver.Value) []driver.Value {
+			return []driver.Value{id, "1.02", "Kesehatan", program(id), "Program"}
+		})
+		return "bidang urusan program", rows, nil
+	case strings.Contains(query, "FROM tb_rencana_aksi ra"):
+		each(func(id driver.Value) []driver.Value {
+			return []driver.Value{id, fmt.Sprint("RENAKSI-", id), int64(3), int64(100)}
+		})
+		return "rencana aksi", rows, nil
+	}
+	return "", nil, fmt.Errorf("countingDB: query tidak dikenal:\n%s", query)
+}
+
+type countingRows struct {
+	values [][]driver.Value
+}
+
+// Columns hanya jumlahnya yang dipakai Scan
+func (r *countingRows) Columns() []string {
+	if len(r.values) == 0 {
+		return nil
+	}
+	return make([]string, len(r.values[0]))
+}
+
+func (r *countingRows) Close() error { return nil }
+
+func (r *countingRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
+
+// laporanStatements menjalankan loadLaporan lewat mysqlRepository atas n pokin,
+// mengembalikan statement yang dieksekusi dan pokin laporan
+func laporanStatements(t testing.TB, n int) ([]string, []Pokin) {
+	t.Helper()
+	tags, err := loadTagRegistry("")
+	if err != nil {
+		t.Fatalf("tag registry: %v", err)
+	}
+	fake := &countingDB{pokins: n}
+	db := sql.OpenDB(fake)
+	defer db.Close()
+	srv := newServer(NewMySQLRepository(db, tags), newMetrics(), ReportConfig{})
+
+	pokins, _, _, err := srv.loadLaporan(context.Background(), laporanParams{
+		NamaTagging: testNamaTagging,
+		Tahun:       2025,
+		Bulanan:     true,
+	})
+	if err != nil {
+		t.Fatalf("loadLaporan: %v", err)
+	}
+	return fake.statements, pokins
+}
+
+func TestLoadLaporanStatementCountIndependentOfPokins(t *testing.T) {
+	small, smallPokins := laporanStatements(t, 10)
+	large, largePokins := laporanStatements(t, 1000)
+
+	if len(smallPokins) != 10 || len(largePokins) != 1000 {
+		t.Fatalf("jumlah pokin: %d dan %d, want 10 dan 1000", len(smallPokins), len(largePokins))
+	}
+	// data pelengkap benar-benar terisi, bukan query kosong
+	last := largePokins[len(largePokins)-1]
+	if len(last.Pelaksanas) != 1 || len(last.Indikator) != 1 || len(last.BidangUrusans) != 1 {
+		t.Fatalf("pokin tidak lengkap: %+v", last)
+	}
+	rekin := last.Pelaksanas[0].RencanaKinerjas[0]
+	if rekin.Pagu != 1000000 || len(rekin.IndikatorPrograms) != 1 || rekin.TahapanPelaksanaan.Tw1 != 100 || len(rekin.RencanaAksis) != 1 {
+		t.Fatalf("rekin tidak lengkap: %+v", rekin)
+	}
+
+	if len(small) != len(large) {
+		t.Errorf("jumlah statement berubah saat pokin bertambah:\n10 pokin: %d %v\n1000 pokin: %d %v",
+			len(small), small, len(large), large)
+	}
+	seen := make(map[string]bool)
+	for _, name := range large {
+		if seen[name] {
+			t.Errorf("statement %q dieksekusi lebih dari sekali", name)
+		}
+		seen[name] = true
+	}
+}
+
+func BenchmarkLoadLaporan(b *testing.B) {
+	for _, n := range []int{10, 100, 1000} {
+		b.Run(fmt.Sprintf("pokins=%d", n), func(b *testing.B) {
+			tags, err := loadTagRegistry("")
+			if err != nil {
+				b.Fatalf("tag registry: %v", err)
+			}
+			fake := &countingDB{pokins: n}
+			db := sql.OpenDB(fake)
+			defer db.Close()
+			srv := newServer(NewMySQLRepository(db, tags), newMetrics(), ReportConfig{})
+			params := laporanParams{NamaTagging: testNamaTagging, Tahun: 2025, Bulanan: true}
+
+			b.ResetTimer()
+			for range b.N {
+				if _, _, _, err := srv.loadLaporan(context.Background(), params); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.StopTimer()
+
+			b.ReportMetric(float64(fake.count())/float64(b.N), "statements/op")
+		})
+	}
+}
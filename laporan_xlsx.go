@@ -0,0 +1,169 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+// kolom sheet laporan tagging, satu baris per rencana kinerja
+var laporanXLSXColumns = []struct {
+	title string
+	width float64
+}{
+	{"No", 5},
+	{"Perangkat Daerah", 28},
+	{"Program Unggulan", 28},
+	{"Rencana Implementasi", 28},
+	{"Pohon Kinerja", 32},
+	{"Jenis Pohon", 12},
+	{"Indikator", 32},
+	{"Target", 10},
+	{"Satuan", 10},
+	{"Pelaksana", 26},
+	{"Rencana Kinerja", 34},
+	{"Program", 30},
+	{"Sub Kegiatan", 30},
+	{"Pagu (Rp)", 16},
+	{"Keterangan", 20},
+}
+
+// index kolom yang dipakai untuk merge dan total
+const (
+	xlsxColPokinLast  = 8 // No s/d Satuan di-merge per pokin
+	xlsxColPelaksana  = 9 // di-merge per pelaksana
+	xlsxColPagu       = 13
+	xlsxColKeterangan = 14
+)
+
+// renderLaporanXLSX menulis laporan tagging dalam format form Laporan Tagging:
+// judul, header, baris per rekin dengan merge per pokin dan pelaksana,
+// jumlah pagu per perangkat daerah dan total keseluruhan
+func renderLaporanXLSX(w io.Writer, report TagPokin) error {
+	sheet := &xlsxSheet{name: "Laporan Tagging", freezeRows: 5}
+	lastCol := len(laporanXLSXColumns) - 1
+
+	header := make([]xlsxCell, len(laporanXLSXColumns))
+	for i, c := range laporanXLSXColumns {
+		sheet.colWidths = append(sheet.colWidths, c.width)
+		header[i] = xlsxText(c.title, xlsxStyleHeader)
+	}
+
+	row := sheet.addRow(xlsxText("LAPORAN TAGGING POHON KINERJA", xlsxStyleTitle))
+	sheet.merge(0, row, lastCol, row)
+	sheet.addRow(xlsxText("Tagging", xlsxStyleDefault), xlsxText(": "+report.NamaTagging, xlsxStyleDefault))
+	sheet.addRow(xlsxText("Tahun", xlsxStyleDefault), xlsxText(fmt.Sprintf(": %d", report.Tahun), xlsxStyleDefault))
+	sheet.addRow()
+	sheet.addRow(header...)
+
+	no := 0
+	for _, opd := range groupByOpd(report.PohonKinerjas) {
+		for _, p := range opd.Pokins {
+			no++
+			addPokinXLSXRows(sheet, no, p)
+		}
+
+		row := sheet.addRow(totalXLSXRow("JUMLAH PAGU "+kodeNama(opd.KodeOpd, opd.NamaOpd), opd.Pagu)...)
+		sheet.merge(0, row, xlsxColPagu-1, row)
+	}
+
+	row = sheet.addRow(totalXLSXRow("TOTAL PAGU", totalPagu(report.PohonKinerjas))...)
+	sheet.merge(0, row, xlsxColPagu-1, row)
+
+	return writeXLSX(w, sheet)
+}
+
+func addPokinXLSXRows(sheet *xlsxSheet, no int, p Pokin) {
+	indikator, target, satuan := indikatorColumns(p, "\n")
+	pokinCells := []xlsxCell{
+		xlsxNumber(int64(no), xlsxStyleText),
+		xlsxText(kodeNama(p.KodeOpd, p.NamaOpd), xlsxStyleText),
+		xlsxText(kodeNama(p.KodeProgramUnggulan, p.NamaProgramUnggulan), xlsxStyleText),
+		xlsxText(p.RencanaImplementasi, xlsxStyleText),
+		xlsxText(p.NamaPohon, xlsxStyleText),
+		xlsxText(string(p.JenisPohon), xlsxStyleText),
+		xlsxText(indikator, xlsxStyleText),
+		xlsxText(target, xlsxStyleText),
+		xlsxText(satuan, xlsxStyleText),
+	}
+	blankPokin := make([]xlsxCell, len(pokinCells))
+	for i := range blankPokin {
+		blankPokin[i] = xlsxBlank(xlsxStyleText)
+	}
+
+	firstRow := 0
+	lastRow := 0
+	for _, pel := range p.Pelaksanas {
+		pelFirst := 0
+		for _, rk := range pel.RencanaKinerjas {
+			cells := blankPokin
+			if firstRow == 0 {
+				cells = pokinCells
+			}
+			pelCell := xlsxBlank(xlsxStyleText)
+			if pelFirst == 0 {
+				pelCell = xlsxText(pel.NamaPelaksana+"\nNIP "+pel.NIPPelaksana, xlsxStyleText)
+			}
+
+			row := sheet.addRow(append(append([]xlsxCell{}, cells...),
+				pelCell,
+				xlsxText(rk.RencanaKinerja, xlsxStyleText),
+				xlsxText(kodeNama(rk.KodeProgram, rk.NamaProgram), xlsxStyleText),
+				xlsxText(kodeNama(rk.KodeSubkegiatan, rk.NamaSubkegiatan), xlsxStyleText),
+				xlsxNumber(int64(rk.Pagu), xlsxStyleNumber),
+				xlsxText(rk.Catatan, xlsxStyleText),
+			)...)
+
+			if firstRow == 0 {
+				firstRow = row
+			}
+			if pelFirst == 0 {
+				pelFirst = row
+			}
+			lastRow = row
+		}
+		if pelFirst > 0 {
+			sheet.merge(xlsxColPelaksana, pelFirst, xlsxColPelaksana, lastRow)
+		}
+	}
+
+	// pokin tanpa rekin tetap satu baris
+	if firstRow == 0 {
+		cells := append([]xlsxCell{}, pokinCells...)
+		for col := xlsxColPelaksana; col <= xlsxColKeterangan; col++ {
+			cells = append(cells, xlsxBlank(xlsxStyleText))
+		}
+		sheet.addRow(cells...)
+		return
+	}
+
+	for col := 0; col <= xlsxColPokinLast; col++ {
+		sheet.merge(col, firstRow, col, lastRow)
+	}
+}
+
+func totalXLSXRow(label string, pagu int64) []xlsxCell {
+	cells := []xlsxCell{xlsxText(label, xlsxStyleTotalLabel)}
+	for col := 1; col < xlsxColPagu; col++ {
+		cells = append(cells, xlsxBlank(xlsxStyleTotalLabel))
+	}
+	return append(cells, xlsxNumber(pagu, xlsxStyleTotalNumber), xlsxBlank(xlsxStyleTotalLabel))
+}
+
+// writeLaporanXLSX membalas laporan sebagai file xlsx, workbook dirender ke buffer dulu
+// supaya error render masih bisa dibalas 500 JSON
+func writeLaporanXLSX(w http.ResponseWriter, r *http.Request, report TagPokin) {
+	var buf bytes.Buffer
+	if err := renderLaporanXLSX(&buf, report); err != nil {
+		loggerFrom(r.Context()).Error("render xlsx gagal", "nama_tagging", report.NamaTagging, "tahun", report.Tahun, "err", err)
+		writeError(w, http.StatusInternalServerError, errCodeInternal, "laporan xlsx gagal dibuat")
+		return
+	}
+
+	attachment(w, xlsxContentType, exportFilename(report.NamaTagging, report.Tahun, formatXLSX))
+	w.Header().Set("Content-Length", strconv.Itoa(buf.Len()))
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf.Bytes())
+}
@@ -2,1021 +2,355 @@ package main
 
 import (
 	"context"
+	"crypto/subtle"
 	"database/sql"
 	"encoding/json"
+	"flag"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
+	"net/url"
 	"os"
+	"os/signal"
 	"strconv"
 	"strings"
+	"syscall"
 	"time"
-
-	_ "github.com/go-sql-driver/mysql"
 )
 
-var db *sql.DB
-
-func initDB() {
-	dsn := os.Getenv("PERENCANAAN_DB_URL")
-	if dsn == "" {
-		log.Fatal("PERENCANAAN_DB_URL env tidak terdefinisi")
-	}
-
-	var err error
-	db, err = sql.Open("mysql", dsn)
-	if err != nil {
-		log.Fatalf("[FATAL] Error connecting to db: %v", err)
-	}
-
-	log.Printf("koneksi ke database berhasil")
-	db.SetMaxOpenConns(70)
-	db.SetMaxIdleConns(300)
-	db.SetConnMaxIdleTime(5 * time.Minute)
-	db.SetConnMaxLifetime(60 * time.Minute)
-
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	err = db.PingContext(ctx)
-	if err != nil {
-		log.Printf("Gagal terhubung ke database dalam 10 detik: %v", err)
-		log.Printf("Mencoba koneksi ulang...")
-
-		// Coba lagi dengan timeout yang lebih lama
-		ctx, cancel = context.WithTimeout(context.Background(), 30*time.Second)
-		defer cancel()
-
-		err = db.PingContext(ctx)
-		if err != nil {
-			db.Close()
-			log.Fatalf("Koneksi database gagal setelah percobaan ulang: %v", err)
-		}
-	}
-
-	log.Print("Berhasil terhubung ke database")
-	log.Printf("Max Open Connections: %d", db.Stats().MaxOpenConnections)
-	log.Printf("Open Connections: %d", db.Stats().OpenConnections)
-	log.Printf("In Use Connections: %d", db.Stats().InUse)
-	log.Printf("Idle Connections: %d", db.Stats().Idle)
+type server struct {
+	repo    TaggingRepository
+	metrics *metrics
+	report  ReportConfig
 }
 
-// parent = strategic
-// child = tactical
-func getBidangUrusanByPokinIdParent(idPokinParent int) (BidangUrusan, error) {
-	rows, err := db.Query(`
-	select bidur.kode_bidang_urusan, bidur.nama_bidang_urusan
-	from tb_rencana_kinerja rekin
-	LEFT JOIN tb_subkegiatan_terpilih sub_rekin ON sub_rekin.rekin_id = rekin.id
-	LEFT JOIN tb_bidang_urusan bidur ON bidur.kode_bidang_urusan = SUBSTRING(sub_rekin.kode_subkegiatan, 1, 4)
-	where rekin.id_pohon in (
-		select pokin.id from tb_pohon_kinerja pokin
-		where pokin.parent in (
-			select id
-			from tb_pohon_kinerja
-			where parent = ? and jenis_pohon in ('Tactical', 'Tactical Pemda')
-		) and jenis_pohon in ('Operational', 'Operational Pemda')
-	) LIMIT 1;`, idPokinParent)
-
-	if err != nil {
-		return BidangUrusan{}, fmt.Errorf("query error: %w", err)
-	}
-	defer rows.Close()
+func newServer(repo TaggingRepository, m *metrics, report ReportConfig) *server {
+	return &server{repo: repo, metrics: m, report: report}
+}
 
-	var bidUr BidangUrusan
-	for rows.Next() {
-		if err := rows.Scan(&bidUr.KodeBidangUrusan, &bidUr.NamaBidangUrusan); err != nil {
-			if err == sql.ErrNoRows {
-				return BidangUrusan{}, fmt.Errorf("bidang urusan tidak ditemukan")
-			}
-			return BidangUrusan{}, fmt.Errorf("query error: %w", err)
-		}
-	}
-	return bidUr, nil
+// laporanParams parameter bersama /laporan/tagging_pokin dan /laporan/tagging_pokin/summary
+type laporanParams struct {
+	NamaTagging string
+	Tahun       int
+	// partial=true: jika pelaksana/indikator gagal, pokin tetap dikirim dengan warnings
+	Partial bool
+	// bulanan=true: rekin dilengkapi bobot 12 bulan dan rencana aksinya
+	Bulanan bool
+	Query   PokinQuery
 }
 
-// parent = tactical
-// child = operational
-func getProgramByPokinIdParent(idPokinParent int) (Program, error) {
-	rows, err := db.Query(`
-	select prg.kode_program, prg.nama_program
-	from tb_rencana_kinerja rekin
-	LEFT JOIN tb_subkegiatan_terpilih sub_rekin ON sub_rekin.rekin_id = rekin.id
-	LEFT JOIN tb_master_program prg
-			ON prg.kode_program = SUBSTRING(sub_rekin.kode_subkegiatan, 1, 7)
-	where rekin.id_pohon in (
-		select pokin.id from tb_pohon_kinerja pokin
-		where pokin.parent = ? and jenis_pohon in ('Operational', 'Operational Pemda')
-	) LIMIT 1;`, idPokinParent)
+func parseLaporanParams(values url.Values) (laporanParams, []FieldError) {
+	var fields []FieldError
+	p := laporanParams{NamaTagging: values.Get("nama_tagging")}
+	if p.NamaTagging == "" {
+		fields = append(fields, FieldError{Field: "nama_tagging", Message: "wajib diisi, misal: ?nama_tagging=tagAbc"})
+	}
 
-	if err != nil {
-		return Program{}, fmt.Errorf("query error: %w", err)
+	tahunStr := values.Get("tahun")
+	tahun, err := strconv.Atoi(tahunStr)
+	switch {
+	case tahunStr == "":
+		fields = append(fields, FieldError{Field: "tahun", Message: "wajib diisi, misal: ?tahun=2025"})
+	case err != nil:
+		fields = append(fields, FieldError{Field: "tahun", Message: "harus berupa angka"})
 	}
-	defer rows.Close()
+	p.Tahun = tahun
 
-	var prg Program
-	for rows.Next() {
-		if err := rows.Scan(&prg.KodeProgram, &prg.NamaProgram); err != nil {
-			if err == sql.ErrNoRows {
-				return Program{}, fmt.Errorf("bidang urusan tidak ditemukan")
-			}
-			return Program{}, fmt.Errorf("query error: %w", err)
+	if v := values.Get("partial"); v != "" {
+		p.Partial, err = strconv.ParseBool(v)
+		if err != nil {
+			fields = append(fields, FieldError{Field: "partial", Message: "harus true atau false"})
 		}
 	}
-	return prg, nil
-}
 
-func getIndikatorProgram(kodeProgram string, kodeOpd string, tahun int) ([]IndikatorProgram, error) {
-	rows, err := db.Query(`
-			SELECT im.indikator
-			FROM tb_indikator_matrix im
-			WHERE im.kode = ?
-			AND im.kode_opd = ?
-			AND im.tahun  = ?
-			AND im.jenis  = 'penetapan'
-			ORDER BY im.kode_indikator
-	`, kodeProgram, kodeOpd, tahun)
-	if err != nil {
-		return nil, fmt.Errorf("query error: %w", err)
+	bulanan, fieldErr := parseBulanan(values)
+	if fieldErr != nil {
+		fields = append(fields, *fieldErr)
 	}
+	p.Bulanan = bulanan
 
-	defer rows.Close()
+	q, queryFields := parsePokinQuery(values)
+	p.Query = q
+	fields = append(fields, queryFields...)
+	return p, fields
+}
 
-	indikators := make([]IndikatorProgram, 0)
-	var indPrg IndikatorProgram
-	for rows.Next() {
-		if err := rows.Scan(&indPrg.Indikator); err != nil {
-			if err == sql.ErrNoRows {
-				return nil, nil
-			}
-			return nil, fmt.Errorf("query error: %w", err)
+// documentPagingErrors menolak page dan size untuk format dokumen
+func documentPagingErrors(values url.Values, format string) []FieldError {
+	var fields []FieldError
+	for _, name := range []string{"page", "size"} {
+		if values.Has(name) {
+			fields = append(fields, FieldError{Field: name, Message: "tidak bisa dipakai dengan format=" + format + ", dokumen selalu berisi semua pokin"})
 		}
-
-		indikators = append(indikators, indPrg)
 	}
-
-	return indikators, nil
+	return fields
 }
 
-func getPelaksanaanRenaksi(idRekin string) (WaktuPelaksanaan, error) {
-	query := `
-		SELECT renaksi.bulan, renaksi.bobot
-		FROM tb_pelaksanaan_rencana_aksi renaksi
-		JOIN tb_rencana_aksi ON tb_rencana_aksi.id = renaksi.rencana_aksi_id
-		JOIN tb_rencana_kinerja rekin ON tb_rencana_aksi.rencana_kinerja_id = rekin.id
-		WHERE rekin.id = ?`
+func (s *server) laporanHandler(w http.ResponseWriter, r *http.Request) {
+	params, fields := parseLaporanParams(r.URL.Query())
 
-	rows, err := db.Query(query, idRekin)
+	format, err := parseFormat(r.URL.Query().Get("format"), formatXLSX, formatCSV, formatPDF)
 	if err != nil {
-		return WaktuPelaksanaan{}, fmt.Errorf("query error: %w", err)
+		fields = append(fields, FieldError{Field: "format", Message: err.Error()})
 	}
-	defer rows.Close()
-
-	var result WaktuPelaksanaan
-
-	for rows.Next() {
-		var bulan, bobot int
-		if err := rows.Scan(&bulan, &bobot); err != nil {
-			log.Printf("[ERROR] scan renaksi error: %v", err)
-			return WaktuPelaksanaan{}, fmt.Errorf("scan error: %w", err)
-		}
-
-		switch {
-		case bulan >= 1 && bulan <= 3:
-			result.Tw1 += bobot
-		case bulan >= 4 && bulan <= 6:
-			result.Tw2 += bobot
-		case bulan >= 7 && bulan <= 9:
-			result.Tw3 += bobot
-		case bulan >= 10 && bulan <= 12:
-			result.Tw4 += bobot
-		}
+	// dokumen berisi total pagu seluruh laporan, tidak bisa per halaman
+	if format == formatXLSX || format == formatPDF {
+		fields = append(fields, documentPagingErrors(r.URL.Query(), format)...)
 	}
 
-	if err := rows.Err(); err != nil {
-		return WaktuPelaksanaan{}, fmt.Errorf("rows error: %w", err)
+	if len(fields) > 0 {
+		writeError(w, http.StatusBadRequest, errCodeInvalidParam, "parameter tidak valid", fields...)
+		return
 	}
+	tag, tahun := params.NamaTagging, params.Tahun
 
-	return result, nil
-}
-
-func getPaguByPokinIds(idPokins []int) (map[string]Pagu, error) {
-	if len(idPokins) == 0 {
-		return map[string]Pagu{}, nil
+	start := time.Now()
+	listPokin, total, warnings, err := s.loadLaporan(r.Context(), params)
+	if err != nil {
+		writeQueryError(w, r, err)
+		return
 	}
 
-	placeholders := make([]string, len(idPokins))
-	args := make([]any, len(idPokins))
-
-	for i, id := range idPokins {
-		placeholders[i] = "?"
-		args[i] = id
+	// hanya tag yang ada datanya, supaya label nama_tagging tidak diisi input sembarang
+	if len(listPokin) > 0 {
+		s.metrics.laporanDuration.observe(time.Since(start).Seconds(), tag, strconv.Itoa(tahun))
 	}
 
-	query := fmt.Sprintf(`
-		SELECT
-			rekin.id,
-			SUM(rinbel.anggaran) AS total_pagu
-		FROM tb_rencana_kinerja rekin
-		JOIN tb_pohon_kinerja pokin ON rekin.id_pohon = pokin.id
-		JOIN tb_rencana_aksi renaksi ON renaksi.rencana_kinerja_id = rekin.id
-		JOIN tb_rincian_belanja rinbel ON rinbel.renaksi_id = renaksi.id
-		WHERE pokin.id IN (%s)
-		GROUP BY rekin.id
-	`, strings.Join(placeholders, ","))
-
-	rows, err := db.Query(query, args...)
-	if err != nil {
-		return nil, err
+	// laporan sebagian tidak boleh tersimpan di cache
+	if len(warnings) > 0 {
+		w.Header().Set("Cache-Control", "no-store")
 	}
-	defer rows.Close()
-
-	result := make(map[string]Pagu)
-
-	for rows.Next() {
-		var id string
-		var total sql.NullInt64
-
-		if err := rows.Scan(&id, &total); err != nil {
-			return nil, err
-		}
 
-		if total.Valid {
-			result[id] = Pagu(total.Int64)
-		}
+	report := TagPokin{
+		NamaTagging:   tag,
+		Tahun:         Tahun(tahun),
+		PohonKinerjas: listPokin,
 	}
 
-	return result, nil
-}
-
-func getPaguByPokin(idPokin int) (map[string]Pagu, error) {
-	query := `
-		SELECT
-			rekin.id,
-			SUM(rinbel.anggaran) AS total_pagu
-		FROM tb_rencana_kinerja rekin
-		JOIN tb_pohon_kinerja pokin ON rekin.id_pohon = pokin.id
-		JOIN tb_rencana_aksi renaksi ON renaksi.rencana_kinerja_id = rekin.id
-		JOIN tb_rincian_belanja rinbel ON rinbel.renaksi_id = renaksi.id
-		WHERE pokin.id = ?
-		GROUP BY rekin.id
-	`
-
-	rows, err := db.Query(query, idPokin)
-	if err != nil {
-		return nil, err
+	switch format {
+	case formatXLSX:
+		writeLaporanXLSX(w, r, report)
+		return
+	case formatCSV:
+		writePokinsCSV(w, r, exportFilename(tag, Tahun(tahun), formatCSV), listPokin)
+		return
+	case formatPDF:
+		writeLaporanPDF(w, r, report, s.report)
+		return
 	}
-	defer rows.Close()
 
-	result := make(map[string]Pagu)
-
-	for rows.Next() {
-		var id string
-		var total sql.NullInt64
-		if err := rows.Scan(&id, &total); err != nil {
-			return nil, err
-		}
-		if total.Valid {
-			result[id] = Pagu(total.Int64)
-		}
+	response := Response{
+		Status:   http.StatusOK,
+		Message:  "Laporan Tagging Pohon Kinerja",
+		Data:     report,
+		Warnings: warnings,
+		Meta:     newMeta(params.Query, total),
 	}
 
-	return result, nil
+	writeJSON(w, http.StatusOK, response)
 }
 
-type IdPokinsJenisPohon struct {
-	idPokin    int
-	jenisPohon string
-}
+// loadLaporan mengambil pokin tagging lalu melengkapinya dengan pelaksana dan rekin,
+// indikator, bidang urusan dan program. Langkah pelengkap yang gagal hanya jadi warning
+// jika params.Partial, kecuali deadline habis atau client putus. Tanpa partial, kegagalan
+// sebagian di repository (*PartialError) juga menggagalkan laporan.
+func (s *server) loadLaporan(ctx context.Context, params laporanParams) ([]Pokin, int, []Warning, error) {
+	tag, tahun := params.NamaTagging, params.Tahun
 
-func getRencanaKinerjaByIdPokins(req []IdPokinsJenisPohon, tahun int) (map[int][]PelaksanaPokin, error) {
-	result := make(map[int][]PelaksanaPokin)
-
-	if len(req) == 0 {
-		return result, nil
-	}
-
-	// map pokin → jenis
-	jenisMap := make(map[int]string)
-	var idPokins []int
-
-	for _, r := range req {
-		idPokins = append(idPokins, r.idPokin)
-		jenisMap[r.idPokin] = r.jenisPohon
-	}
-
-	// pagu batch
-	paguMap, err := getPaguByPokinIds(idPokins)
-	if err != nil {
-		return nil, err
-	}
-
-	// placeholder query
-	placeholders := make([]string, len(idPokins))
-	args := make([]any, len(idPokins))
-
-	for i, id := range idPokins {
-		placeholders[i] = "?"
-		args[i] = id
-	}
-
-	query := fmt.Sprintf(`
-	SELECT DISTINCT
-	       pokin.id,
-	       rekin.id,
-	       rekin.nama_rencana_kinerja,
-	       pegawai.nama,
-	       pegawai.nip,
-	       subkegiatan.kode_subkegiatan,
-	       subkegiatan.nama_subkegiatan,
-	       prog.kode_program,
-               prog.nama_program,
-	       rekin.catatan,
-               rekin.kode_opd
-	FROM tb_rencana_kinerja rekin
-	JOIN tb_pegawai pegawai ON pegawai.nip = rekin.pegawai_id
-	JOIN tb_pohon_kinerja pokin ON rekin.id_pohon = pokin.id
-	LEFT JOIN tb_subkegiatan_terpilih sub_rekin ON sub_rekin.rekin_id = rekin.id
-	LEFT JOIN tb_subkegiatan subkegiatan ON subkegiatan.kode_subkegiatan = sub_rekin.kode_subkegiatan
-        LEFT JOIN tb_master_program prog ON prog.kode_program = SUBSTRING_INDEX(sub_rekin.kode_subkegiatan, '.', 3)
-	WHERE rekin.kode_opd = pokin.kode_opd
-	AND pokin.id IN (%s)
-	`, strings.Join(placeholders, ","))
-
-	rows, err := db.Query(query, args...)
+	listPokin, total, err := s.repo.GetPokinByTagging(ctx, tag, tahun, params.Query)
 	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	// pokin → nip → pelaksana
-	pelaksanaMap := make(map[int]map[string]*PelaksanaPokin)
-	seen := make(map[int]map[string]map[string]Pagu)
-
-	for rows.Next() {
-
-		var pokinId int
-		var rekin RencanaKinerjaAsn
-		var kodeSub, namaSub,
-			kodePrg, namaPrg sql.NullString
-		var kodeOpd string
-
-		if err := rows.Scan(
-			&pokinId,
-			&rekin.IdRekin,
-			&rekin.RencanaKinerja,
-			&rekin.NamaPelaksana,
-			&rekin.NIPPelaksana,
-			&kodeSub,
-			&namaSub,
-			&kodePrg,
-			&namaPrg,
-			&rekin.Catatan,
-			&kodeOpd,
-		); err != nil {
-			return nil, err
-		}
-
-		if kodeSub.Valid {
-			rekin.KodeSubkegiatan = kodeSub.String
-		}
-		if namaSub.Valid {
-			rekin.NamaSubkegiatan = namaSub.String
-		}
-
-		if kodePrg.Valid {
-			rekin.KodeProgram = kodePrg.String
-
-			indPrograms, err := getIndikatorProgram(rekin.KodeProgram, kodeOpd, tahun)
-			if err != nil {
-				log.Printf("failed to get indikator program %s: %v", rekin.KodeProgram, err)
-			} else {
-				rekin.IndikatorPrograms = indPrograms
-			}
-		}
-
-		if namaPrg.Valid {
-			rekin.NamaProgram = namaPrg.String
-		}
-
-		if p, ok := paguMap[rekin.IdRekin]; ok {
-			rekin.Pagu = p
-		}
-
-		// init maps
-		if _, ok := pelaksanaMap[pokinId]; !ok {
-			pelaksanaMap[pokinId] = make(map[string]*PelaksanaPokin)
-			seen[pokinId] = make(map[string]map[string]Pagu)
-		}
-
-		key := rekin.NIPPelaksana
-
-		if _, ok := pelaksanaMap[pokinId][key]; !ok {
-			pelaksanaMap[pokinId][key] = &PelaksanaPokin{
-				NamaPelaksana: rekin.NamaPelaksana,
-				NIPPelaksana:  rekin.NIPPelaksana,
-			}
-			seen[pokinId][key] = make(map[string]Pagu)
-		}
-
-		if existing, ok := seen[pokinId][key][rekin.IdRekin]; ok {
-
-			seen[pokinId][key][rekin.IdRekin] = existing + rekin.Pagu
-
-			for i := range pelaksanaMap[pokinId][key].RencanaKinerjas {
-				if pelaksanaMap[pokinId][key].RencanaKinerjas[i].IdRekin == rekin.IdRekin {
-					pelaksanaMap[pokinId][key].RencanaKinerjas[i].Pagu =
-						seen[pokinId][key][rekin.IdRekin]
-				}
-			}
-
-		} else {
-
-			seen[pokinId][key][rekin.IdRekin] = rekin.Pagu
-			pelaksanaMap[pokinId][key].RencanaKinerjas =
-				append(pelaksanaMap[pokinId][key].RencanaKinerjas, rekin)
-		}
-	}
-
-	if err := rows.Err(); err != nil {
-		return nil, err
+		return nil, 0, nil, err
 	}
 
-	// convert map
-	for pokinId, pelaksanas := range pelaksanaMap {
-
-		for _, p := range pelaksanas {
-			result[pokinId] = append(result[pokinId], *p)
+	// untuk req pelaksana
+	reqPelaksana := make([]IdPokinsJenisPohon, len(listPokin))
+	idPokins := make([]int, len(listPokin))
+	for i, po := range listPokin {
+		reqPelaksana[i] = IdPokinsJenisPohon{
+			idPokin:    po.IdPohon,
+			jenisPohon: string(po.JenisPohon),
 		}
+		idPokins[i] = po.IdPohon
 	}
 
-	return result, nil
-}
-
-func getRencanaKinerjaPokin(idPokin int, jenisPohon string) ([]PelaksanaPokin, error) {
-	paguMap, err := getPaguByPokin(idPokin)
-	if err != nil {
-		return nil, err
-	}
-
-	query := `
-		SELECT DISTINCT rekin.id,
-		       rekin.nama_rencana_kinerja,
-		       pegawai.nama,
-		       pegawai.nip,
-		       subkegiatan.kode_subkegiatan,
-		       subkegiatan.nama_subkegiatan,
-               rekin.catatan
-		FROM tb_rencana_kinerja rekin
-		JOIN tb_pegawai pegawai ON pegawai.nip = rekin.pegawai_id
-		JOIN tb_pohon_kinerja pokin ON rekin.id_pohon = pokin.id
-		LEFT JOIN tb_subkegiatan_terpilih sub_rekin ON sub_rekin.rekin_id = rekin.id
-		LEFT JOIN tb_subkegiatan subkegiatan ON subkegiatan.kode_subkegiatan = sub_rekin.kode_subkegiatan
-		WHERE rekin.kode_opd = pokin.kode_opd AND pokin.id = ?`
-
-	rows, err := db.Query(query, idPokin)
-	if err != nil {
-		return nil, fmt.Errorf("query error: %w", err)
-	}
-	defer rows.Close()
-
-	pelaksanaRekins := make(map[string]*PelaksanaPokin)
-	seen := make(map[string]map[string]Pagu) // key: nip -> id_rekin -> total pagu
-
-	for rows.Next() {
-		var rekin RencanaKinerjaAsn
-		var kodeSub, namaSub sql.NullString
-
-		if err := rows.Scan(
-			&rekin.IdRekin,
-			&rekin.RencanaKinerja,
-			&rekin.NamaPelaksana,
-			&rekin.NIPPelaksana,
-			&kodeSub,
-			&namaSub,
-			&rekin.Catatan,
-		); err != nil {
-			log.Printf("[ERROR] scan rekin error: %v", err)
-			return nil, fmt.Errorf("scan error: %w", err)
+	var warnings []Warning
+	// enrichmentFailed mengembalikan error jika laporan tidak boleh dilanjutkan
+	enrichmentFailed := func(step, message string, err error) error {
+		loggerFrom(ctx).Error("enrichment laporan gagal", "step", step, "nama_tagging", tag, "tahun", tahun, "err", err)
+		if !params.Partial || ctx.Err() != nil {
+			return err
 		}
-
-		// Handle NULL dengan NullString/NullInt64
-		if kodeSub.Valid {
-			rekin.KodeSubkegiatan = kodeSub.String
-		}
-		if namaSub.Valid {
-			rekin.NamaSubkegiatan = namaSub.String
-		}
-
-		if p, ok := paguMap[rekin.IdRekin]; ok {
-			rekin.Pagu = p
-		}
-
-		rekin.KodeBidangUrusan = "-"
-		rekin.NamaBidangUrusan = "-"
-		if jenisPohon == "Strategic" || jenisPohon == "Strategic Pemda" {
-			if bidangUrusan, err := getBidangUrusanByPokinIdParent(idPokin); err == nil {
-				rekin.KodeBidangUrusan = bidangUrusan.KodeBidangUrusan
-				rekin.NamaBidangUrusan = bidangUrusan.NamaBidangUrusan
-			} else {
-				log.Printf("[ERROR] Get Urusan error: %v", err)
-			}
-		}
-
-		rekin.KodeProgram = "-"
-		rekin.NamaProgram = "-"
-		if jenisPohon == "Tactical" || jenisPohon == "Tactical Pemda" {
-			if program, err := getProgramByPokinIdParent(idPokin); err == nil {
-				rekin.KodeProgram = program.KodeProgram
-				rekin.NamaProgram = program.NamaProgram
-			} else {
-				log.Printf("[ERROR] Get Program error: %v", err)
+		// hasil sebagian dari repository: warning per langkah yang gagal, datanya tetap dipakai
+		if partials := partialErrors(err); len(partials) > 0 {
+			for _, pe := range partials {
+				warnings = append(warnings, Warning{Step: pe.Step, Message: pe.Message})
 			}
+			return nil
 		}
-
-		// renaksi / tahapan
-		pelaksanaanRenaksi, err := getPelaksanaanRenaksi(rekin.IdRekin)
-		if err != nil {
-			log.Printf("[ERROR] Get Renaksi error: %v", err)
-			return nil, fmt.Errorf("getRPelaksanaanRenaksi: %w", err)
-		}
-		rekin.TahapanPelaksanaan = pelaksanaanRenaksi
-
-		key := rekin.NIPPelaksana
-		if _, ok := pelaksanaRekins[key]; !ok {
-			pelaksanaRekins[key] = &PelaksanaPokin{
-				NamaPelaksana:   rekin.NamaPelaksana,
-				NIPPelaksana:    rekin.NIPPelaksana,
-				RencanaKinerjas: []RencanaKinerjaAsn{},
-			}
-			seen[key] = make(map[string]Pagu)
-		}
-
-		if existingPagu, ok := seen[key][rekin.IdRekin]; ok {
-			seen[key][rekin.IdRekin] = existingPagu + rekin.Pagu
-			for i := range pelaksanaRekins[key].RencanaKinerjas {
-				if pelaksanaRekins[key].RencanaKinerjas[i].IdRekin == rekin.IdRekin {
-					pelaksanaRekins[key].RencanaKinerjas[i].Pagu = seen[key][rekin.IdRekin]
-					break
-				}
-			}
-		} else {
-			seen[key][rekin.IdRekin] = rekin.Pagu
-			pelaksanaRekins[key].RencanaKinerjas = append(pelaksanaRekins[key].RencanaKinerjas, rekin)
-		}
+		warnings = append(warnings, Warning{Step: step, Message: message})
+		return nil
 	}
 
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("rows error: %w", err)
+	pelaksanas, err := s.repo.GetRencanaKinerjaByIdPokins(ctx, reqPelaksana, tahun)
+	if err != nil {
+		if err := enrichmentFailed("pelaksana", "pelaksana dan rencana kinerja gagal dimuat", err); err != nil {
+			return nil, 0, nil, err
+		}
 	}
-
-	// convert map ke slice
-	var pelaksanas []PelaksanaPokin
-	for _, p := range pelaksanaRekins {
-		pelaksanas = append(pelaksanas, *p)
+	for i := range listPokin {
+		listPokin[i].Pelaksanas = pelaksanas[listPokin[i].IdPohon]
 	}
 
-	return pelaksanas, nil
-}
-
-func getIndikatorPokinbyIdPokins(idPokins []int) (map[int][]IndikatorPohon, error) {
-	result := make(map[int][]IndikatorPohon)
-
-	if len(idPokins) == 0 {
-		return result, nil
+	indikatorPokins, err := s.repo.GetIndikatorPokinByIdPokins(ctx, idPokins)
+	if err != nil {
+		if err := enrichmentFailed("indikator", "indikator dan target pohon kinerja gagal dimuat", err); err != nil {
+			return nil, 0, nil, err
+		}
 	}
-
-	placeholders := make([]string, len(idPokins))
-	args := make([]any, len(idPokins))
-
-	for i, id := range idPokins {
-		placeholders[i] = "?"
-		args[i] = id
+	for i := range listPokin {
+		listPokin[i].Indikator = indikatorPokins[listPokin[i].IdPohon]
 	}
 
-	query := fmt.Sprintf(`
-	SELECT
-		ind.id,
-		ind.pokin_id,
-		ind.indikator,
-		tgt.id,
-		tgt.target,
-		tgt.satuan,
-		tgt.tahun
-	FROM tb_indikator ind
-	LEFT JOIN tb_target tgt ON tgt.indikator_id = ind.id
-	WHERE ind.pokin_id IN (%s)
-	ORDER BY ind.pokin_id, ind.id
-	`, strings.Join(placeholders, ","))
-
-	rows, err := db.Query(query, args...)
+	bidangPrograms, err := s.repo.GetBidangUrusanProgramByIdPokins(ctx, idPokins)
 	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	// untuk deduplicate indikator
-	indikatorMap := make(map[string]*IndikatorPohon)
-
-	for rows.Next() {
-
-		var (
-			indId     string
-			pokinId   int
-			indikator string
-			tgtId     sql.NullString
-			tgtVal    sql.NullString
-			satuan    sql.NullString
-			tahun     sql.NullInt64
-		)
-
-		err := rows.Scan(
-			&indId,
-			&pokinId,
-			&indikator,
-			&tgtId,
-			&tgtVal,
-			&satuan,
-			&tahun,
-		)
-		if err != nil {
-			return nil, err
+		if err := enrichmentFailed("bidang_urusan_program", "bidang urusan dan program gagal dimuat", err); err != nil {
+			return nil, 0, nil, err
 		}
-
-		// unique key indikator
-		key := fmt.Sprintf("%d-%s", pokinId, indId)
-
-		ind, exists := indikatorMap[key]
-		if !exists {
-
-			newInd := IndikatorPohon{
-				IdIndikator: indId,
-				IdPokin:     strconv.Itoa(pokinId),
-				Indikator:   indikator,
-				Target:      []TargetIndikator{},
+	}
+	for i := range listPokin {
+		po := &listPokin[i]
+		bp := bidangPrograms[po.IdPohon]
+		po.BidangUrusans = append([]BidangUrusan{}, bp.BidangUrusans...)
+		po.Programs = append([]Program{}, bp.Programs...)
+		for j := range po.Pelaksanas {
+			for k := range po.Pelaksanas[j].RencanaKinerjas {
+				applyBidangUrusanProgram(&po.Pelaksanas[j].RencanaKinerjas[k], bp)
 			}
-
-			indikatorMap[key] = &newInd
-			result[pokinId] = append(result[pokinId], newInd)
-
-			ind = &result[pokinId][len(result[pokinId])-1]
 		}
+	}
 
-		// tambah target jika ada
-		if tgtId.Valid {
-
-			target := TargetIndikator{
-				IdTarget:    tgtId.String,
-				IndikatorId: indId,
-				Target:      tgtVal.String,
-				Satuan:      satuan.String,
-			}
-
-			if tahun.Valid {
-				target.Tahun = int(tahun.Int64)
+	if params.Bulanan {
+		if err := s.applyRencanaAksi(ctx, listPokin); err != nil {
+			if err := enrichmentFailed("rencana_aksi", "jadwal bulanan rencana aksi gagal dimuat", err); err != nil {
+				return nil, 0, nil, err
 			}
-
-			ind.Target = append(ind.Target, target)
 		}
 	}
 
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
+	// urutan pokin sudah dari repository (ikut halaman), tinggal isinya
+	sortPokinChildren(listPokin)
 
-	return result, nil
+	return listPokin, total, warnings, nil
 }
 
-func laporanHandler(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		http.Error(w, "method not allowed, pakai GET", http.StatusMethodNotAllowed)
-		return
-	}
-
-	namaTaggingStr := r.URL.Query().Get("nama_tagging")
-	if namaTaggingStr == "" {
-		http.Error(w, "params nama_tagging is required, misal: ?nama_tagging=tagAbc", http.StatusBadRequest)
-		return
-	}
+func (s *server) getDetailHandler(w http.ResponseWriter, r *http.Request) {
+	// kode program unggulan
+	kode := r.PathValue("kode")
 
-	tahunStr := r.URL.Query().Get("tahun")
-	if tahunStr == "" {
-		http.Error(w, "params tahun is required, misal: ?tahun=123", http.StatusBadRequest)
+	dq, ok := parseDetailQuery(w, r)
+	if !ok {
 		return
 	}
 
-	tahun, err := strconv.Atoi(tahunStr)
+	listPokin, err := s.repo.GetDetailByKodeProgramUnggulan(r.Context(), kode)
 	if err != nil {
-		http.Error(w, "invalid tahun", http.StatusBadRequest)
+		writeQueryError(w, r, err)
 		return
 	}
-	tag := namaTaggingStr
-
-	var query string
-
-	switch tag {
-	case "RB":
-		query = `
-        SELECT
-            pokin.id,
-            pokin.nama_pohon,
-            pokin.tahun,
-            pokin.jenis_pohon,
-            pokin.kode_opd,
-            opd.nama_opd,
-            tag.keterangan_tagging,
-            pokin.status,
-            rb.id,
-            rb.kegiatan_utama,
-	    '-',
-	    '-',
-            pokin.keterangan
-        FROM tb_pohon_kinerja pokin
-        JOIN tb_operasional_daerah opd ON opd.kode_opd = pokin.kode_opd
-        JOIN tb_tagging_pokin tag ON tag.id_pokin = pokin.id
-            AND pokin.tahun = ?
-            AND pokin.kode_opd != ""
-            AND pokin.status IN ("pokin dari pemda", "")
-        JOIN tb_keterangan_tagging_program_unggulan prung ON prung.id_tagging = tag.id
-        JOIN datamaster_rb rb ON prung.kode_program_unggulan = rb.id
-        WHERE tag.nama_tagging = ?
-    `
-	default:
-		query = `
-        SELECT
-            pokin.id,
-            pokin.nama_pohon,
-            pokin.tahun,
-            pokin.jenis_pohon,
-            pokin.kode_opd,
-            opd.nama_opd,
-            tag.keterangan_tagging,
-            pokin.status,
-            prog.id as id_program_unggulan,
-            prog.kode_program_unggulan,
-            prog.nama_tagging,
-	    prog.keterangan_program_unggulan,
-            pokin.keterangan
-        FROM tb_pohon_kinerja pokin
-        JOIN tb_operasional_daerah opd ON opd.kode_opd = pokin.kode_opd
-        JOIN tb_tagging_pokin tag ON tag.id_pokin = pokin.id
-            AND pokin.tahun = ?
-            AND pokin.kode_opd != ""
-            AND pokin.status IN ("pokin dari pemda", "")
-        JOIN tb_keterangan_tagging_program_unggulan prung ON prung.id_tagging = tag.id
-        JOIN tb_program_unggulan prog ON prung.kode_program_unggulan = prog.kode_program_unggulan
-        WHERE tag.nama_tagging = ?
-    `
-	}
-
-	rows, err := db.Query(query, tahun, tag)
-	if err != nil {
-		http.Error(w, "query error: "+err.Error(), http.StatusInternalServerError)
-		return
-	}
-	defer rows.Close()
-
-	// List Pokin
-	var reqPelaksana []IdPokinsJenisPohon
-	var listPokin []Pokin
-	for rows.Next() {
-		var (
-			idPohon             int
-			namaPohon           sql.NullString
-			tahun               sql.NullInt64
-			jenisPohon          sql.NullString
-			kodeOpd             sql.NullString
-			namaOpd             sql.NullString
-			keteranganTagging   sql.NullString
-			status              sql.NullString
-			puId                sql.NullInt64
-			kodeProgramUnggulan sql.NullString
-			namaProgramUnggulan sql.NullString
-			rencanaImplementasi sql.NullString
-			keterangan          sql.NullString
-		)
-
-		if err := rows.Scan(&idPohon, &namaPohon, &tahun, &jenisPohon, &kodeOpd, &namaOpd, &keteranganTagging, &status, &puId, &kodeProgramUnggulan, &namaProgramUnggulan, &rencanaImplementasi, &keterangan); err != nil {
-			http.Error(w, "scan error: "+err.Error(), http.StatusInternalServerError)
+	// detail tidak punya query tahun, purge cache per tahun memakai tahun pokin
+	addCacheTahun(r.Context(), listPokin)
+	if dq.Bulanan {
+		if err := s.applyRencanaAksi(r.Context(), listPokin); err != nil {
+			writeQueryError(w, r, err)
 			return
 		}
-
-		strJenisPohon := toStr(jenisPohon)
-
-		var idProgramUnggulan int
-		if puId.Valid {
-			idProgramUnggulan = int(puId.Int64)
-		}
-
-		po := Pokin{
-			IdProgramUnggulan:   idProgramUnggulan,
-			KodeProgramUnggulan: toStr(kodeProgramUnggulan),
-			NamaProgramUnggulan: toStr(namaProgramUnggulan),
-			IdPohon:             idPohon,
-			NamaPohon:           toStr(namaPohon),
-			Tahun:               Tahun(tahunToInt(tahun)),
-			JenisPohon:          JenisPohon(strJenisPohon),
-			KodeOpd:             toStr(kodeOpd),
-			NamaOpd:             toStr(namaOpd),
-			RencanaImplementasi: toStr(rencanaImplementasi),
-			KeteranganTagging:   toStr(keteranganTagging),
-			Status:              toStr(status),
-			Keterangan:          toStr(keterangan),
-		}
-
-		// untuk req pelaksana
-		reqPelaksana = append(reqPelaksana, IdPokinsJenisPohon{
-			idPokin:    idPohon,
-			jenisPohon: strJenisPohon,
-		})
-
-		listPokin = append(listPokin, po)
-	}
-	pelaksanas, err := getRencanaKinerjaByIdPokins(reqPelaksana, tahun)
-	if err != nil {
-		log.Printf("[ERROR] Get Rekin Pokin error: %v", err)
-		return
-	}
-	for i := range listPokin {
-		listPokin[i].Pelaksanas = pelaksanas[listPokin[i].IdPohon]
 	}
+	sortPokinsBy(listPokin, dq.Sort)
 
-	idPokins := make([]int, len(reqPelaksana))
-	for i, r := range reqPelaksana {
-		idPokins[i] = r.idPokin
-	}
-	indikatorPokins, err := getIndikatorPokinbyIdPokins(idPokins)
-	if err != nil {
-		log.Printf("[ERROR] Get INDIKATOR Pokin error: %v", err)
+	if dq.Format == formatCSV {
+		writePokinsCSV(w, r, "detail-program-unggulan-"+safeFilename(kode)+".csv", listPokin)
 		return
 	}
-	for i := range idPokins {
-		listPokin[i].Indikator = indikatorPokins[listPokin[i].IdPohon]
-	}
 
 	response := Response{
 		Status:  http.StatusOK,
 		Message: "Laporan Tagging Pohon Kinerja",
-		Data: TagPokin{
-			NamaTagging:   tag,
-			Tahun:         Tahun(tahun),
-			PohonKinerjas: listPokin,
-		},
+		Data:    listPokin,
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+	writeJSON(w, http.StatusOK, response)
 }
 
-func getDetailHandler(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		http.Error(w, "method not allowed, pakai GET", http.StatusMethodNotAllowed)
+func (s *server) getDetailBatchHandler(w http.ResponseWriter, r *http.Request) {
+	// kode program unggulan
+	var req struct {
+		KodeProgramUnggulan []string `json:"kode_program_unggulan"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		loggerFrom(r.Context()).Warn("gagal decode body", "err", err)
+		writeError(w, http.StatusBadRequest, errCodeInvalidBody, "body request harus JSON yang valid")
 		return
 	}
+	defer r.Body.Close()
 
-	path := r.URL.Path
-	parts := strings.Split(path, "/")
+	if len(req.KodeProgramUnggulan) == 0 {
+		writeError(w, http.StatusBadRequest, errCodeInvalidParam, "body request tidak valid",
+			FieldError{Field: "kode_program_unggulan", Message: "wajib diisi dan minimal 1"})
+		return
+	}
 
-	if len(parts) < 4 {
-		http.Error(w, "KODE tidak ditemukan", http.StatusBadRequest)
+	// urutan dan format tetap lewat query string, sama dengan endpoint GET
+	dq, ok := parseDetailQuery(w, r)
+	if !ok {
 		return
 	}
 
-	// kode program unggulan
-	kode := parts[3]
-
-	rows, err := db.Query(`SELECT
-                            ket.kode_program_unggulan,
-                            pu.nama_tagging,
-                            pu.keterangan_program_unggulan,
-                            ket.id_tagging,
-                            pokin.id,
-							pokin.nama_pohon,
-							pokin.tahun,
-							pokin.jenis_pohon,
-							opd.kode_opd,
-                            opd.nama_opd,
-                            ind.id,
-							ind.indikator AS indikator_pokin,
-                            tgt.id,
-                            tgt.target AS target_indikator_pokin,
-                            tgt.satuan AS satuan_target_indikator_pokin,
-                            tgt.tahun AS tahun_target
-                           FROM tb_keterangan_tagging_program_unggulan ket
-                           JOIN tb_program_unggulan pu ON pu.kode_program_unggulan = ket.kode_program_unggulan
-                           JOIN tb_tagging_pokin tag ON tag.id = ket.id_tagging
-                           LEFT JOIN tb_pohon_kinerja pokin ON pokin.id = tag.id_pokin
-						   LEFT JOIN tb_operasional_daerah opd ON opd.kode_opd = pokin.kode_opd
-						   LEFT JOIN tb_indikator ind ON ind.pokin_id = pokin.id
-                           LEFT JOIN tb_target tgt ON tgt.indikator_id = ind.id
-                           WHERE ket.kode_program_unggulan = ?`, kode)
+	listPokin, err := s.repo.GetDetailBatchByKodeProgramUnggulan(r.Context(), req.KodeProgramUnggulan)
 	if err != nil {
-		http.Error(w, "query error: "+err.Error(), http.StatusInternalServerError)
+		writeQueryError(w, r, err)
 		return
 	}
-	defer rows.Close()
-
-	// List Pokin
-	// var listPokin []Pokin
-	// map[id_pohon]Pokin
-	pokinMap := make(map[int]*Pokin)
-	for rows.Next() {
-		var (
-			pok       Pokin
-			indId     sql.NullString
-			indikator sql.NullString
-			tgtId     sql.NullString
-			tgtVal    sql.NullString
-			satuan    sql.NullString
-			tahun     sql.NullInt64
-		)
-
-		err := rows.Scan(
-			&pok.KodeProgramUnggulan,
-			&pok.NamaProgramUnggulan,
-			&pok.RencanaImplementasi,
-			&pok.IdTagging,
-			&pok.IdPohon,
-			&pok.NamaPohon,
-			&pok.Tahun,
-			&pok.JenisPohon,
-			&pok.KodeOpd,
-			&pok.NamaOpd,
-			&indId,
-			&indikator,
-			&tgtId,
-			&tgtVal,
-			&satuan,
-			&tahun,
-		)
-		if err != nil {
-			http.Error(w, "scan error: "+err.Error(), http.StatusInternalServerError)
-			return
-		}
 
-		// Ambil pokin atau buat baru
-		existing, ok := pokinMap[pok.IdPohon]
-		if !ok {
-			existing = &Pokin{
-				KodeProgramUnggulan: pok.KodeProgramUnggulan,
-				NamaProgramUnggulan: pok.NamaProgramUnggulan,
-				RencanaImplementasi: pok.RencanaImplementasi,
-				IdTagging:           pok.IdTagging,
-				IdPohon:             pok.IdPohon,
-				NamaPohon:           pok.NamaPohon,
-				Tahun:               pok.Tahun,
-				JenisPohon:          pok.JenisPohon,
-				KodeOpd:             pok.KodeOpd,
-				NamaOpd:             pok.NamaOpd,
-				Indikator:           []IndikatorPohon{},
+	// list id rekin -> untuk pagu
+	var idRekins []string
+	for _, p := range listPokin {
+		for _, pel := range p.Pelaksanas {
+			for _, rekin := range pel.RencanaKinerjas {
+				idRekins = append(idRekins, rekin.IdRekin)
 			}
-			pokinMap[pok.IdPohon] = existing
 		}
+	}
 
-		// Tambahkan indikator (jika ada)
-		if indId.Valid {
-			indikatorFound := false
-			for i := range existing.Indikator {
-				if existing.Indikator[i].IdIndikator == indId.String {
-					indikatorFound = true
-					// Tambahkan target
-					if tgtId.Valid {
-						existing.Indikator[i].Target = append(existing.Indikator[i].Target, TargetIndikator{
-							IdTarget: tgtId.String,
-							Target:   tgtVal.String,
-							Satuan:   satuan.String,
-							Tahun:    int(tahun.Int64),
-						})
-					}
-					break
-				}
-			}
+	// ADD PAGU TO REKIN
+	paguMap, err := s.repo.GetPaguByRekinIds(r.Context(), idRekins)
+	if err != nil {
+		writeQueryError(w, r, err)
+		return
+	}
+	for _, p := range listPokin {
+		for i := range p.Pelaksanas {
+			pel := &p.Pelaksanas[i]
 
-			if !indikatorFound {
-				newInd := IndikatorPohon{
-					IdIndikator: indId.String,
-					Indikator:   indikator.String, // atau nama kolom indikator
-					Target:      []TargetIndikator{},
-				}
+			for j := range pel.RencanaKinerjas {
+				rekin := &pel.RencanaKinerjas[j]
 
-				if tgtId.Valid {
-					newInd.Target = append(newInd.Target, TargetIndikator{
-						IdTarget: tgtId.String,
-						Target:   tgtVal.String,
-						Satuan:   satuan.String,
-						Tahun:    int(tahun.Int64),
-					})
+				if p, ok := paguMap[rekin.IdRekin]; ok {
+					rekin.Pagu = p
 				}
-
-				existing.Indikator = append(existing.Indikator, newInd)
 			}
 		}
 	}
+	if dq.Bulanan {
+		if err := s.applyRencanaAksi(r.Context(), listPokin); err != nil {
+			writeQueryError(w, r, err)
+			return
+		}
+	}
+	sortPokinsBy(listPokin, dq.Sort)
 
-	// ubah ke slice
-	var listPokin []Pokin
-	for _, p := range pokinMap {
-		listPokin = append(listPokin, *p)
+	if dq.Format == formatCSV {
+		writePokinsCSV(w, r, "detail-program-unggulan.csv", listPokin)
+		return
 	}
 
 	response := Response{
@@ -1025,319 +359,254 @@ func getDetailHandler(w http.ResponseWriter, r *http.Request) {
 		Data:    listPokin,
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+	writeJSON(w, http.StatusOK, response)
 }
 
-func getDetailBatchHandler(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		http.Error(w, "method not allowed, pakai POST", http.StatusMethodNotAllowed)
-		return
-	}
+type detailQuery struct {
+	Sort    PokinSort
+	Format  string
+	Bulanan bool
+}
 
-	// kode program unggulansss
-	var req struct {
-		KodeProgramUnggulan []string `json:"kode_program_unggulan" validate:"required,min=1"`
+// parseDetailQuery membaca ?sort=, ?format= dan ?bulanan= endpoint detail, false jika sudah dibalas 400
+func parseDetailQuery(w http.ResponseWriter, r *http.Request) (detailQuery, bool) {
+	var fields []FieldError
+	var dq detailQuery
+	var err error
+	dq.Sort, err = parseSort(r.URL.Query().Get("sort"))
+	if err != nil {
+		fields = append(fields, FieldError{Field: "sort", Message: err.Error()})
 	}
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, fmt.Sprintf("gagal decode body: %v", err), http.StatusBadRequest)
-		return
+	dq.Format, err = parseFormat(r.URL.Query().Get("format"), formatCSV)
+	if err != nil {
+		fields = append(fields, FieldError{Field: "format", Message: err.Error()})
 	}
-	defer r.Body.Close()
-
-	if len(req.KodeProgramUnggulan) == 0 {
-		http.Error(w, "kode_program_unggulan wajib diisi dan minimal 1", http.StatusBadRequest)
-		return
+	bulanan, fieldErr := parseBulanan(r.URL.Query())
+	if fieldErr != nil {
+		fields = append(fields, *fieldErr)
 	}
+	dq.Bulanan = bulanan
+	if len(fields) > 0 {
+		writeError(w, http.StatusBadRequest, errCodeInvalidParam, "parameter tidak valid", fields...)
+		return detailQuery{}, false
+	}
+	return dq, true
+}
 
-	// Siapkan query dynamic (WHERE IN (...))
-	placeholders := make([]string, len(req.KodeProgramUnggulan))
-	args := make([]any, len(req.KodeProgramUnggulan))
-	for i, kode := range req.KodeProgramUnggulan {
-		placeholders[i] = "?"
-		args[i] = kode
-	}
-
-	query := fmt.Sprintf(`
-        SELECT
-            pu.id as id_program_unggulan,
-            ket.kode_program_unggulan,
-            pu.nama_tagging,
-            pu.keterangan_program_unggulan,
-            ket.id_tagging,
-            pokin.id,
-            pokin.nama_pohon,
-            pokin.tahun,
-            pokin.jenis_pohon,
-            opd.kode_opd,
-            opd.nama_opd,
-            ind.id,
-            ind.indikator AS indikator_pokin,
-            tgt.id,
-            tgt.target AS target_indikator_pokin,
-            tgt.satuan AS satuan_target_indikator_pokin,
-            tgt.tahun AS tahun_target,
-            tp.pegawai_id,
-            peg.nama,
-            peg.nip,
-            rekin.id as rekin_id,
-            rekin.nama_rencana_kinerja,
-            sub.kode_subkegiatan,
-            sub.nama_subkegiatan
-        FROM tb_keterangan_tagging_program_unggulan ket
-        JOIN tb_program_unggulan pu ON pu.kode_program_unggulan = ket.kode_program_unggulan
-        JOIN tb_tagging_pokin tag ON tag.id = ket.id_tagging
-        LEFT JOIN tb_pohon_kinerja pokin ON pokin.id = tag.id_pokin
-        LEFT JOIN tb_operasional_daerah opd ON opd.kode_opd = pokin.kode_opd
-        LEFT JOIN tb_indikator ind ON ind.pokin_id = pokin.id
-        LEFT JOIN tb_target tgt ON tgt.indikator_id = ind.id
-        LEFT JOIN tb_pelaksana_pokin tp ON tp.pohon_kinerja_id = pokin.id
-        LEFT JOIN tb_pegawai peg ON tp.pegawai_id = peg.id
-        LEFT JOIN tb_rencana_kinerja rekin ON pokin.id = rekin.id_pohon AND peg.nip = rekin.pegawai_id
-        LEFT JOIN tb_rencana_aksi renaksi ON rekin.id = renaksi.rencana_kinerja_id
-        LEFT JOIN tb_subkegiatan_terpilih tst ON tst.rekin_id = rekin.id
-        LEFT JOIN tb_subkegiatan sub ON tst.subkegiatan_id = sub.id
-        WHERE ket.kode_program_unggulan IN (%s)
-    `, strings.Join(placeholders, ","))
-
-	rows, err := db.Query(query, args...)
+func parseBulanan(values url.Values) (bool, *FieldError) {
+	v := values.Get("bulanan")
+	if v == "" {
+		return false, nil
+	}
+	bulanan, err := strconv.ParseBool(v)
 	if err != nil {
-		http.Error(w, "query error: "+err.Error(), http.StatusInternalServerError)
-		return
+		return false, &FieldError{Field: "bulanan", Message: "harus true atau false"}
 	}
-	defer rows.Close()
-
-	// List Pokin
-	// var listPokin []Pokin
-	// map[id_pohon]Pokin
-	pokinMap := make(map[int]*Pokin)
-	// list id rekin -> untuk pagu
-	rekinIdSet := make(map[string]struct{})
-	for rows.Next() {
-		var (
-			pok         Pokin
-			puId        sql.NullInt64
-			indId       sql.NullString
-			indikator   sql.NullString
-			tgtId       sql.NullString
-			tgtVal      sql.NullString
-			satuan      sql.NullString
-			tahun       sql.NullInt64
-			pegawaiId   sql.NullString
-			namaPegawai sql.NullString
-			nip         sql.NullString
-			rekinId     sql.NullString
-			rekin       sql.NullString
-			kodeSub     sql.NullString
-			namaSub     sql.NullString
-		)
-
-		err := rows.Scan(
-			&puId,
-			&pok.KodeProgramUnggulan,
-			&pok.NamaProgramUnggulan,
-			&pok.RencanaImplementasi,
-			&pok.IdTagging,
-			&pok.IdPohon,
-			&pok.NamaPohon,
-			&pok.Tahun,
-			&pok.JenisPohon,
-			&pok.KodeOpd,
-			&pok.NamaOpd,
-			&indId,
-			&indikator,
-			&tgtId,
-			&tgtVal,
-			&satuan,
-			&tahun,
-			&pegawaiId,
-			&namaPegawai,
-			&nip,
-			&rekinId,
-			&rekin,
-			&kodeSub,
-			&namaSub,
-		)
-		if err != nil {
-			http.Error(w, "scan error: "+err.Error(), http.StatusInternalServerError)
-			return
-		}
-		var idProgramUnggulan int
-		if puId.Valid {
-			idProgramUnggulan = int(puId.Int64)
-		}
+	return bulanan, nil
+}
 
-		// Ambil pokin atau buat baru
-		existing, ok := pokinMap[pok.IdPohon]
-		if !ok {
-			existing = &Pokin{
-				IdProgramUnggulan:   idProgramUnggulan,
-				KodeProgramUnggulan: pok.KodeProgramUnggulan,
-				NamaProgramUnggulan: pok.NamaProgramUnggulan,
-				RencanaImplementasi: pok.RencanaImplementasi,
-				IdTagging:           pok.IdTagging,
-				IdPohon:             pok.IdPohon,
-				NamaPohon:           pok.NamaPohon,
-				Tahun:               pok.Tahun,
-				JenisPohon:          pok.JenisPohon,
-				KodeOpd:             pok.KodeOpd,
-				NamaOpd:             pok.NamaOpd,
-				Indikator:           []IndikatorPohon{},
-				Pelaksanas:          []PelaksanaPokin{},
+// applyRencanaAksi mengisi rencana aksi dan bobot bulanan semua rekin di pokins
+func (s *server) applyRencanaAksi(ctx context.Context, pokins []Pokin) error {
+	var idRekins []string
+	for _, p := range pokins {
+		for _, pel := range p.Pelaksanas {
+			for _, rk := range pel.RencanaKinerjas {
+				idRekins = append(idRekins, rk.IdRekin)
 			}
-			pokinMap[pok.IdPohon] = existing
 		}
+	}
+	if len(idRekins) == 0 {
+		return nil
+	}
 
-		if pegawaiId.Valid {
-			pelaksana := findPegawaiId(existing, pegawaiId.String)
-
-			if pelaksana == nil {
-				existing.Pelaksanas = append(existing.Pelaksanas, PelaksanaPokin{
-					IdPelaksana:     pegawaiId.String,
-					NamaPelaksana:   namaPegawai.String,
-					NIPPelaksana:    nip.String,
-					RencanaKinerjas: []RencanaKinerjaAsn{},
-				})
-				pelaksana = &existing.Pelaksanas[len(existing.Pelaksanas)-1]
-			}
-
-			if rekinId.Valid {
-				// TAMBAH REKIN ID KE LIST
-				rekinIdSet[rekinId.String] = struct{}{}
-
-				rekins := findRekinId(pelaksana, rekinId.String)
-				if rekins == nil {
-					pelaksana.RencanaKinerjas = append(pelaksana.RencanaKinerjas, RencanaKinerjaAsn{
-						IdRekin:         rekinId.String,
-						RencanaKinerja:  rekin.String,
-						Pagu:            Pagu(0),
-						KodeSubkegiatan: kodeSub.String,
-						NamaSubkegiatan: namaSub.String,
-					})
-					rekins = &pelaksana.RencanaKinerjas[len(pelaksana.RencanaKinerjas)-1]
-				}
+	aksis, err := s.repo.GetRencanaAksiByRekinIds(ctx, idRekins)
+	if err != nil {
+		return err
+	}
+	for i := range pokins {
+		for j := range pokins[i].Pelaksanas {
+			rekins := pokins[i].Pelaksanas[j].RencanaKinerjas
+			for k := range rekins {
+				applyRencanaAksi(&rekins[k], aksis[rekins[k].IdRekin])
 			}
 		}
+	}
+	return nil
+}
 
-		// Tambahkan indikator (jika ada)
-		if indId.Valid {
-			ind := findIndikator(existing, indId.String)
-
-			if ind == nil {
-				existing.Indikator = append(existing.Indikator, IndikatorPohon{
-					IdIndikator: indId.String,
-					Indikator:   indikator.String,
-					Target:      []TargetIndikator{},
-				})
-				ind = &existing.Indikator[len(existing.Indikator)-1]
-			}
-			if tgtVal.Valid {
-				addTargetIfNotExists(
-					ind,
-					tgtId.String,
-					tgtVal.String,
-					satuan.String,
-					int(tahun.Int64),
-				)
-			}
+// adminAuth membatasi endpoint admin dengan header "Authorization: Bearer <token>".
+// Tanpa token terkonfigurasi endpoint admin tidak tersedia sama sekali.
+func adminAuth(token string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if token == "" {
+			writeError(w, http.StatusNotFound, errCodeNotFound, "endpoint admin nonaktif, set ADMIN_TOKEN untuk mengaktifkan")
+			return
 		}
+		got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="admin"`)
+			writeError(w, http.StatusUnauthorized, errCodeUnauthorized, "token admin tidak valid")
+			return
+		}
+		next(w, r)
 	}
-	// END rows.Next()
+}
 
-	// ADD PAGU TO REKIN
-	paguMap := make(map[string]Pagu)
-	if len(rekinIdSet) > 0 {
+// configHandler menampilkan konfigurasi efektif tanpa password database
+func configHandler(cfg Config) http.HandlerFunc {
+	redacted := cfg.Redacted()
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, Response{
+			Status:  http.StatusOK,
+			Message: "Konfigurasi Service",
+			Data:    redacted,
+		})
+	}
+}
 
-		placeholders := make([]string, 0, len(rekinIdSet))
-		argsPagu := make([]any, 0, len(rekinIdSet))
+func main() {
+	cfg, err := LoadConfig(flag.CommandLine, os.Args[1:])
+	if err != nil {
+		fatal("config tidak valid", "err", err)
+	}
 
-		for id := range rekinIdSet {
-			placeholders = append(placeholders, "?")
-			argsPagu = append(argsPagu, id)
-		}
+	logger, err := newLogger(cfg.Log)
+	if err != nil {
+		fatal("config tidak valid", "err", err)
+	}
+	slog.SetDefault(logger)
 
-		queryPagu := fmt.Sprintf(`
-        SELECT
-            rekin.id,
-            SUM(bel.anggaran) AS total_pagu
-        FROM tb_rencana_kinerja rekin
-        JOIN tb_rencana_aksi renaksi
-            ON rekin.id = renaksi.rencana_kinerja_id
-        JOIN tb_rincian_belanja bel
-            ON renaksi.id = bel.renaksi_id
-        WHERE rekin.id IN (%s)
-        GROUP BY rekin.id
-    `, strings.Join(placeholders, ","))
-
-		rowsPagu, err := db.Query(queryPagu, argsPagu...)
-		if err != nil {
-			http.Error(w, err.Error(), 500)
-			return
-		}
-		defer rowsPagu.Close()
+	slog.Info("LAPORAN TAGGING POHON KINERJA", "version", version)
+	slog.Info("config", "config", cfg.Redacted())
 
-		for rowsPagu.Next() {
-			var id string
-			var total sql.NullInt64
+	tags, err := loadTagRegistry(cfg.TagSourcesPath)
+	if err != nil {
+		fatal("tag source tidak valid", "err", err)
+	}
 
-			if err := rowsPagu.Scan(&id, &total); err != nil {
-				http.Error(w, err.Error(), 500)
-				return
-			}
+	// context background worker, dibatalkan saat shutdown
+	bgCtx, stopBackground := context.WithCancel(context.Background())
+	defer stopBackground()
 
-			if total.Valid {
-				paguMap[id] = Pagu(total.Int64)
-			}
+	ready := &readiness{}
+
+	// pool database, nil di mode demo
+	var db *sql.DB
+	var repo TaggingRepository
+	if cfg.Demo {
+		fx, err := loadDemoFixture(cfg.FixturePath)
+		if err != nil {
+			fatal("fixture demo tidak valid", "path", cfg.FixturePath, "err", err)
 		}
+		slog.Warn("MODE DEMO: data dari fixture, database tidak dipakai")
+		repo = NewMemoryRepository(fx, tags)
+		ready.set(true)
+	} else {
+		db, err = openDB(cfg.DB)
+		if err != nil {
+			fatal("Error connecting to db", "err", err)
+		}
+		go connectDB(bgCtx, db, cfg.DB, ready)
+		repo = NewMySQLRepository(db, tags)
+	}
+
+	m := newMetrics()
+	cache := newResponseCache(cfg.Cache, m)
+	srv := newServer(newInstrumentedRepository(repo, m), m, cfg.Report)
+
+	// semua route dicatat di metrics dengan label pola route
+	rt := newRouter(m.instrument)
+	rt.handle(http.MethodGet, "/health", healthHandler(db, time.Duration(cfg.Timeouts.Health)))
+	rt.handle(http.MethodGet, "/ready", ready.handler)
+	rt.handle(http.MethodGet, "/metrics", m.handler(db, cache))
+	rt.handle(http.MethodGet, "/admin/config", adminAuth(cfg.AdminToken, configHandler(cfg)))
+	rt.handle(http.MethodPost, "/admin/cache/purge", adminAuth(cfg.AdminToken, cache.purgeHandler))
+	// endpoint laporan: ETag/304 -> cache response -> timeout -> handler
+	versions := newVersionTracker()
+	rt.handle(http.MethodGet, "/laporan/tagging_pokin", versions.conditional("/laporan/tagging_pokin",
+		cache.middleware("/laporan/tagging_pokin",
+			withTimeout(time.Duration(cfg.Timeouts.Laporan), srv.laporanHandler))))
+	rt.handle(http.MethodGet, "/laporan/tagging_pokin/summary", versions.conditional("/laporan/tagging_pokin/summary",
+		cache.middleware("/laporan/tagging_pokin/summary",
+			withTimeout(time.Duration(cfg.Timeouts.Laporan), srv.summaryHandler))))
+	rt.handle(http.MethodGet, "/tagging/getDetail/{kode}", versions.conditional("/tagging/getDetail/{kode}",
+		cache.middleware("/tagging/getDetail/{kode}",
+			withTimeout(time.Duration(cfg.Timeouts.Detail), srv.getDetailHandler))))
+	rt.handle(http.MethodPost, "/tagging/getDetailBatch", versions.conditional("/tagging/getDetailBatch",
+		withTimeout(time.Duration(cfg.Timeouts.DetailBatch), srv.getDetailBatchHandler)))
+
+	handler := requestIDMiddleware(accessLogMiddleware(recoverMiddleware(corsMiddleware(rt))))
+
+	httpServer := &http.Server{
+		Addr:              cfg.Addr(),
+		Handler:           handler,
+		ReadHeaderTimeout: time.Duration(cfg.Server.ReadHeaderTimeout),
+		ReadTimeout:       time.Duration(cfg.Server.ReadTimeout),
+		// download laporan bisa lama, divalidasi lebih besar dari timeout endpoint terlama
+		WriteTimeout: time.Duration(cfg.Server.WriteTimeout),
+		IdleTimeout:  time.Duration(cfg.Server.IdleTimeout),
+	}
+
+	err = serve(httpServer, ready, time.Duration(cfg.Server.ShutdownGrace), time.Duration(cfg.Server.ShutdownTimeout))
+	stopBackground()
+	closeDB(db)
+	if err != nil {
+		slog.Error("server error", "err", err)
+		os.Exit(1)
 	}
-	// END get PAGU
-	// ubah ke slice
-	var listPokin []Pokin
-	for _, p := range pokinMap {
-		listPokin = append(listPokin, *p)
-		// add pagu to rekins
-		for i := range p.Pelaksanas {
-			pel := &p.Pelaksanas[i]
+	slog.Info("server berhenti")
+}
 
-			for j := range pel.RencanaKinerjas {
-				rekin := &pel.RencanaKinerjas[j]
+// serve menjalankan server sampai SIGINT/SIGTERM, lalu menandai /ready tidak siap,
+// menunggu grace supaya tidak ada request baru dari load balancer,
+// dan menunggu request yang sedang berjalan selesai maksimal shutdownTimeout
+func serve(srv *http.Server, ready *readiness, grace, shutdownTimeout time.Duration) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-				if p, ok := paguMap[rekin.IdRekin]; ok {
-					rekin.Pagu = p
-				}
-			}
-		}
-	}
+	errCh := make(chan error, 1)
+	go func() {
+		slog.Info("Server running", "addr", srv.Addr)
+		errCh <- srv.ListenAndServe()
+	}()
 
-	response := Response{
-		Status:  http.StatusOK,
-		Message: "Laporan Tagging Pohon Kinerja",
-		Data:    listPokin,
+	select {
+	case err := <-errCh:
+		return fmt.Errorf("listen %s: %w", srv.Addr, err)
+	case <-ctx.Done():
 	}
+	stop()
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
-}
-func healthCheckHandler(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	w.Write([]byte(`{"status":"LAPORAN TAGGING POHON KINERJA UP"}`))
-}
-
-func main() {
-	log.Print("LAPORAN TAGGING POHON KINERJA")
+	ready.shutdown()
+	if grace > 0 {
+		slog.Info("sinyal berhenti diterima, /ready tidak siap", "shutdown_grace", grace.String())
+		time.Sleep(grace)
+	}
 
-	initDB()
+	slog.Info("menunggu request selesai", "shutdown_timeout", shutdownTimeout.String())
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
 
-	http.HandleFunc("/health", healthCheckHandler)
-	http.HandleFunc("/laporan/tagging_pokin", laporanHandler)
-	http.HandleFunc("/tagging/getDetail/", getDetailHandler)
-	http.HandleFunc("/tagging/getDetailBatch", getDetailBatchHandler)
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("shutdown: %w", err)
+	}
+	return nil
+}
 
-	handler := corsMiddleware(http.DefaultServeMux)
-	log.Println("Server running di :8080")
+// fixture dari file jika path diisi, selain itu fixture bawaan
+func loadDemoFixture(path string) (*Fixture, error) {
+	if path != "" {
+		return LoadFixture(path)
+	}
+	return ParseFixture(demoFixture)
+}
 
-	http.ListenAndServe(":8080", handler)
+// registry dari file jika path diisi, selain itu konfigurasi bawaan
+func loadTagRegistry(path string) (*TagRegistry, error) {
+	if path != "" {
+		return LoadTagRegistry(path)
+	}
+	return ParseTagRegistry(defaultTagSources)
 }
 
 // Middleware CORS
@@ -1345,8 +614,9 @@ func corsMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// Untuk development, bisa pakai "*"
 		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
 		w.Header().Set("Access-Control-Allow-Headers", "*")
+		w.Header().Set("Access-Control-Expose-Headers", requestIDHeader+", Content-Disposition")
 
 		// Preflight request (OPTIONS)
 		if r.Method == http.MethodOptions {
@@ -0,0 +1,279 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// bucket latency (detik), laporan besar bisa puluhan detik
+var latencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60}
+
+// metrics menyimpan metric service dalam format teks Prometheus,
+// ditulis sendiri supaya tidak butuh dependency tambahan.
+type metrics struct {
+	httpRequests    *counterVec
+	httpDuration    *histogramVec
+	laporanDuration *histogramVec
+	queryDuration   *histogramVec
+	queryErrors     *counterVec
+	cacheRequests   *counterVec
+	cacheEvictions  *counterVec
+}
+
+func newMetrics() *metrics {
+	return &metrics{
+		httpRequests: newCounterVec("http_requests_total",
+			"Jumlah request HTTP per route dan status.", "route", "method", "status"),
+		httpDuration: newHistogramVec("http_request_duration_seconds",
+			"Latency request HTTP per route dan status.", latencyBuckets, "route", "method", "status"),
+		laporanDuration: newHistogramVec("laporan_tagging_duration_seconds",
+			"Latency penyusunan laporan tagging per nama_tagging dan tahun.", latencyBuckets, "nama_tagging", "tahun"),
+		queryDuration: newHistogramVec("repository_query_duration_seconds",
+			"Latency query repository per fungsi.", latencyBuckets, "query"),
+		queryErrors: newCounterVec("repository_query_errors_total",
+			"Jumlah query repository yang gagal per fungsi.", "query"),
+		cacheRequests: newCounterVec("cache_requests_total",
+			"Jumlah request yang melewati cache response per route, result hit atau miss.", "route", "result"),
+		cacheEvictions: newCounterVec("cache_evictions_total",
+			"Jumlah entry cache yang dibuang per alasan.", "reason"),
+	}
+}
+
+// instrument mencatat jumlah dan latency request untuk satu route.
+// route diisi pola route (bukan path asli) supaya label tidak meledak.
+func (m *metrics) instrument(route string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w}
+		next(rec, r)
+
+		status := strconv.Itoa(rec.statusCode())
+		m.httpRequests.inc(route, r.Method, status)
+		m.httpDuration.observe(time.Since(start).Seconds(), route, r.Method, status)
+	}
+}
+
+func (m *metrics) observeQuery(name string, start time.Time, err error) {
+	m.queryDuration.observe(time.Since(start).Seconds(), name)
+	if err != nil {
+		m.queryErrors.inc(name)
+	}
+}
+
+// handler /metrics, gauge pool database dan cache dibaca saat scrape.
+// conn nil di mode demo, cache nil jika cache nonaktif.
+func (m *metrics) handler(conn *sql.DB, cache *responseCache) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+
+		m.httpRequests.write(w)
+		m.httpDuration.write(w)
+		m.laporanDuration.write(w)
+		m.queryDuration.write(w)
+		m.queryErrors.write(w)
+		m.cacheRequests.write(w)
+		m.cacheEvictions.write(w)
+
+		entries, size := cache.stats()
+		fmt.Fprintf(w, "# HELP cache_entries Jumlah entry di cache response.\n# TYPE cache_entries gauge\ncache_entries %d\n", entries)
+		fmt.Fprintf(w, "# HELP cache_bytes Total ukuran entry di cache response.\n# TYPE cache_bytes gauge\ncache_bytes %d\n", size)
+
+		if conn != nil {
+			writeDBStats(w, conn.Stats())
+		}
+	}
+}
+
+func writeDBStats(w io.Writer, s sql.DBStats) {
+	for _, g := range []struct {
+		name, typ, help string
+		value           float64
+	}{
+		{"db_max_open_connections", "gauge", "Batas maksimal koneksi terbuka.", float64(s.MaxOpenConnections)},
+		{"db_open_connections", "gauge", "Jumlah koneksi terbuka.", float64(s.OpenConnections)},
+		{"db_in_use_connections", "gauge", "Jumlah koneksi yang sedang dipakai.", float64(s.InUse)},
+		{"db_idle_connections", "gauge", "Jumlah koneksi idle.", float64(s.Idle)},
+		{"db_wait_count_total", "counter", "Jumlah total menunggu koneksi.", float64(s.WaitCount)},
+		{"db_wait_duration_seconds_total", "counter", "Total waktu menunggu koneksi.", s.WaitDuration.Seconds()},
+		{"db_max_idle_closed_total", "counter", "Koneksi ditutup karena max idle.", float64(s.MaxIdleClosed)},
+		{"db_max_idle_time_closed_total", "counter", "Koneksi ditutup karena max idle time.", float64(s.MaxIdleTimeClosed)},
+		{"db_max_lifetime_closed_total", "counter", "Koneksi ditutup karena max lifetime.", float64(s.MaxLifetimeClosed)},
+	} {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n%s %s\n", g.name, g.help, g.name, g.typ, g.name, formatFloat(g.value))
+	}
+}
+
+// statusRecorder mencatat status dan jumlah byte response
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (rec *statusRecorder) WriteHeader(code int) {
+	if rec.status == 0 {
+		rec.status = code
+	}
+	rec.ResponseWriter.WriteHeader(code)
+}
+
+func (rec *statusRecorder) Write(b []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	n, err := rec.ResponseWriter.Write(b)
+	rec.bytes += n
+	return n, err
+}
+
+func (rec *statusRecorder) Unwrap() http.ResponseWriter { return rec.ResponseWriter }
+
+// request tanpa balasan (client putus) dicatat 499 seperti nginx
+func (rec *statusRecorder) statusCode() int {
+	if rec.status == 0 {
+		return 499
+	}
+	return rec.status
+}
+
+type counterVec struct {
+	name, help string
+	labels     []string
+
+	mu     sync.Mutex
+	values map[string]float64
+}
+
+func newCounterVec(name, help string, labels ...string) *counterVec {
+	return &counterVec{name: name, help: help, labels: labels, values: make(map[string]float64)}
+}
+
+func (c *counterVec) inc(labelValues ...string) {
+	key := formatLabels(c.labels, labelValues)
+	c.mu.Lock()
+	c.values[key]++
+	c.mu.Unlock()
+}
+
+func (c *counterVec) write(w io.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n", c.name, c.help, c.name)
+	for _, key := range sortedKeys(c.values) {
+		fmt.Fprintf(w, "%s%s %s\n", c.name, key, formatFloat(c.values[key]))
+	}
+}
+
+type histogramVec struct {
+	name, help string
+	labels     []string
+	buckets    []float64
+
+	mu     sync.Mutex
+	series map[string]*histogram
+}
+
+type histogram struct {
+	labelValues []string
+	counts      []uint64 // per bucket, belum kumulatif
+	count       uint64
+	sum         float64
+}
+
+func newHistogramVec(name, help string, buckets []float64, labels ...string) *histogramVec {
+	return &histogramVec{name: name, help: help, labels: labels, buckets: buckets, series: make(map[string]*histogram)}
+}
+
+func (h *histogramVec) observe(v float64, labelValues ...string) {
+	key := formatLabels(h.labels, labelValues)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	s, ok := h.series[key]
+	if !ok {
+		s = &histogram{labelValues: labelValues, counts: make([]uint64, len(h.buckets))}
+		h.series[key] = s
+	}
+	for i, upper := range h.buckets {
+		if v <= upper {
+			s.counts[i]++
+			break
+		}
+	}
+	s.count++
+	s.sum += v
+}
+
+func (h *histogramVec) write(w io.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s histogram\n", h.name, h.help, h.name)
+	keys := make([]string, 0, len(h.series))
+	for k := range h.series {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	bucketLabels := append(append([]string{}, h.labels...), "le")
+	for _, key := range keys {
+		s := h.series[key]
+		var cumulative uint64
+		for i, upper := range h.buckets {
+			cumulative += s.counts[i]
+			lbl := formatLabels(bucketLabels, append(append([]string{}, s.labelValues...), formatFloat(upper)))
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, lbl, cumulative)
+		}
+		lbl := formatLabels(bucketLabels, append(append([]string{}, s.labelValues...), "+Inf"))
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, lbl, s.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.name, key, formatFloat(s.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.name, key, s.count)
+	}
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func formatLabels(names, values []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, name := range names {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		var v string
+		if i < len(values) {
+			v = values[i]
+		}
+		b.WriteString(name)
+		b.WriteString(`="`)
+		b.WriteString(labelEscaper.Replace(v))
+		b.WriteByte('"')
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func sortedKeys(m map[string]float64) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// Urutan bawaan semua response laporan, supaya response (dan ETag-nya) sama
+// untuk data yang sama, apa pun urutan hasil query atau iterasi map:
+//
+//	pokin:           kode_opd, level jenis pohon (strategic, tactical, operational), id_pohon, kode_program_unggulan
+//	pelaksana:       nip
+//	rencana kinerja: id_rekin
+//	indikator:       id_indikator
+//	target:          tahun, id_target
+//	bidang urusan / program: kode
+//
+// Urutan pokin bisa diganti dengan parameter ?sort=, lihat parseSort.
+func sortPokins(pokins []Pokin) {
+	sortPokinsBy(pokins, nil)
+}
+
+func defaultPokinCompare(a, b Pokin) int {
+	return cmp.Or(
+		cmp.Compare(a.KodeOpd, b.KodeOpd),
+		cmp.Compare(jenisPohonLevel(a.JenisPohon), jenisPohonLevel(b.JenisPohon)),
+		cmp.Compare(a.IdPohon, b.IdPohon),
+		cmp.Compare(a.KodeProgramUnggulan, b.KodeProgramUnggulan),
+		cmp.Compare(a.IdTagging, b.IdTagging),
+	)
+}
+
+// sortPokinsBy mengurutkan pokin dengan sort lalu urutan bawaan untuk yang sama, juga isi tiap pokin
+func sortPokinsBy(pokins []Pokin, sort PokinSort) {
+	slices.SortStableFunc(pokins, func(a, b Pokin) int {
+		return cmp.Or(sort.compare(a, b), defaultPokinCompare(a, b))
+	})
+	sortPokinChildren(pokins)
+}
+
+// sortPokinChildren hanya mengurutkan isi tiap pokin, urutan pokin dibiarkan
+// (dipakai jika urutan pokin sudah dari ORDER BY query)
+func sortPokinChildren(pokins []Pokin) {
+	for i := range pokins {
+		sortPelaksanas(pokins[i].Pelaksanas)
+		sortIndikators(pokins[i].Indikator)
+	}
+}
+
+func sortPelaksanas(pelaksanas []PelaksanaPokin) {
+	slices.SortStableFunc(pelaksanas, func(a, b PelaksanaPokin) int {
+		return cmp.Compare(a.NIPPelaksana, b.NIPPelaksana)
+	})
+	for i := range pelaksanas {
+		slices.SortStableFunc(pelaksanas[i].RencanaKinerjas, func(a, b RencanaKinerjaAsn) int {
+			return cmp.Compare(a.IdRekin, b.IdRekin)
+		})
+	}
+}
+
+func sortIndikators(indikators []IndikatorPohon) {
+	slices.SortStableFunc(indikators, func(a, b IndikatorPohon) int {
+		return cmp.Compare(a.IdIndikator, b.IdIndikator)
+	})
+	for i := range indikators {
+		slices.SortStableFunc(indikators[i].Target, func(a, b TargetIndikator) int {
+			return cmp.Or(
+				cmp.Compare(a.Tahun, b.Tahun),
+				cmp.Compare(a.IdTarget, b.IdTarget),
+			)
+		})
+	}
+}
+
+// level jenis pohon dari atas ke bawah, jenis lain di paling akhir
+func jenisPohonLevel(j JenisPohon) int {
+	switch j {
+	case "Strategic", "Strategic Pemda":
+		return 1
+	case "Tactical", "Tactical Pemda":
+		return 2
+	case "Operational", "Operational Pemda":
+		return 3
+	}
+	return 4
+}
+
+// kolom yang boleh dipakai di ?sort=, kolom teks dibandingkan seperti collation MySQL (lihat collateCompare)
+var pokinSortKeys = map[string]func(a, b Pokin) int{
+	"kode_opd":              func(a, b Pokin) int { return collateCompare(a.KodeOpd, b.KodeOpd) },
+	"nama_opd":              func(a, b Pokin) int { return collateCompare(a.NamaOpd, b.NamaOpd) },
+	"jenis_pohon":           func(a, b Pokin) int { return cmp.Compare(jenisPohonLevel(a.JenisPohon), jenisPohonLevel(b.JenisPohon)) },
+	"id_pohon":              func(a, b Pokin) int { return cmp.Compare(a.IdPohon, b.IdPohon) },
+	"nama_pohon":            func(a, b Pokin) int { return collateCompare(a.NamaPohon, b.NamaPohon) },
+	"kode_program_unggulan": func(a, b Pokin) int { return collateCompare(a.KodeProgramUnggulan, b.KodeProgramUnggulan) },
+}
+
+// collateCompare mendekati collation *_ci MySQL untuk sort di memori (repository fixture):
+// huruf besar/kecil dianggap sama dan huruf Latin beraksen disamakan dengan huruf dasarnya,
+// sehingga urutan mode demo sama dengan ORDER BY di production.
+// Nilai yang sama menurut collation diurutkan lagi oleh urutan bawaan.
+func collateCompare(a, b string) int {
+	return strings.Compare(collateKey(a), collateKey(b))
+}
+
+func collateKey(s string) string {
+	return strings.Map(func(r rune) rune {
+		if base, ok := latinBase[r]; ok {
+			return base
+		}
+		return unicode.ToLower(r)
+	}, s)
+}
+
+// huruf Latin-1 beraksen -> huruf dasar kecil, seperti utf8mb4_general_ci
+var latinBase = func() map[rune]rune {
+	m := make(map[rune]rune)
+	for base, accented := range map[rune]string{
+		'a': "àáâãäåÀÁÂÃÄÅ", 'c': "çÇ", 'e': "èéêëÈÉÊË", 'i': "ìíîïÌÍÎÏ",
+		'n': "ñÑ", 'o': "òóôõöøÒÓÔÕÖØ", 'u': "ùúûüÙÚÛÜ", 'y': "ýÿÝ",
+	} {
+		for _, r := range accented {
+			m[r] = base
+		}
+	}
+	return m
+}()
+
+// sortKeyNames daftar kolom sort untuk pesan error, urut abjad
+func sortKeyNames() []string {
+	names := make([]string, 0, len(pokinSortKeys))
+	for name := range pokinSortKeys {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// SortField satu kolom sort, Name salah satu kunci pokinSortKeys
+type SortField struct {
+	Name string
+	Desc bool
+}
+
+// PokinSort urutan pokin dari ?sort=, kosong = urutan bawaan
+type PokinSort []SortField
+
+// parseSort membaca ?sort=kode_opd,-id_pohon, awalan "-" untuk urutan menurun
+func parseSort(param string) (PokinSort, error) {
+	if param == "" {
+		return nil, nil
+	}
+
+	var sort PokinSort
+	for _, field := range strings.Split(param, ",") {
+		field = strings.TrimSpace(field)
+		name := strings.TrimPrefix(field, "-")
+		if _, ok := pokinSortKeys[name]; !ok {
+			return nil, fmt.Errorf("kolom %q tidak didukung, pilih: %s", name, strings.Join(sortKeyNames(), ", "))
+		}
+		sort = append(sort, SortField{Name: name, Desc: strings.HasPrefix(field, "-")})
+	}
+	return sort, nil
+}
+
+func (s PokinSort) compare(a, b Pokin) int {
+	for _, f := range s {
+		c := pokinSortKeys[f.Name](a, b)
+		if f.Desc {
+			c = -c
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return 0
+}
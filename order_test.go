@@ -0,0 +1,27 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSortPokinsByNamaFollowsCollation(t *testing.T) {
+	pokins := []Pokin{
+		{IdPohon: 1, NamaPohon: "meningkatnya pelayanan"},
+		{IdPohon: 2, NamaPohon: "Zero stunting"},
+		{IdPohon: 3, NamaPohon: "Éfisiensi belanja"},
+		{IdPohon: 4, NamaPohon: "Meningkatnya pelayanan"},
+		{IdPohon: 5, NamaPohon: "akuntabilitas kinerja"},
+	}
+	sortPokinsBy(pokins, PokinSort{{Name: "nama_pohon"}})
+
+	var got []int
+	for _, p := range pokins {
+		got = append(got, p.IdPohon)
+	}
+	// beda huruf besar/kecil dianggap sama, urutan berikutnya dari id_pohon (urutan bawaan)
+	want := []int{5, 3, 1, 4, 2}
+	if !slices.Equal(got, want) {
+		t.Errorf("urutan id_pohon = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Penulis PDF minimal tanpa dependency: halaman A4, font standar Helvetica (tidak perlu embed font),
+// teks, garis dan kotak. Koordinat API dari kiri atas dalam point (1/72 inci).
+
+const pdfContentType = "application/pdf"
+
+// ukuran A4 landscape
+const (
+	pdfA4Long  = 841.89
+	pdfA4Short = 595.28
+)
+
+type pdfFont struct {
+	resource string // nama resource di content stream
+	base     string // BaseFont standar PDF
+	widths   *[95]int
+}
+
+var (
+	pdfHelvetica     = pdfFont{resource: "F1", base: "Helvetica", widths: &helveticaWidths}
+	pdfHelveticaBold = pdfFont{resource: "F2", base: "Helvetica-Bold", widths: &helveticaBoldWidths}
+)
+
+// width lebar teks dalam point
+func (f pdfFont) width(s string, size float64) float64 {
+	total := 0
+	enc := pdfEncode(s)
+	for i := 0; i < len(enc); i++ {
+		if c := enc[i]; c >= 32 && c <= 126 {
+			total += f.widths[c-32]
+		} else {
+			total += 556
+		}
+	}
+	return float64(total) * size / 1000
+}
+
+// wrap memecah teks per kata supaya tidak melebihi maxWidth, baris baru di teks tetap dipakai
+func (f pdfFont) wrap(s string, size, maxWidth float64) []string {
+	var lines []string
+	for _, para := range strings.Split(s, "\n") {
+		line := ""
+		for _, word := range strings.Fields(para) {
+			// kata yang terlalu panjang dipotong per karakter
+			for f.width(word, size) > maxWidth {
+				if line != "" {
+					lines = append(lines, line)
+					line = ""
+				}
+				cut := len(word)
+				for cut > 1 && (f.width(word[:cut], size) > maxWidth || !utf8.RuneStart(word[cut])) {
+					cut--
+				}
+				lines = append(lines, word[:cut])
+				word = word[cut:]
+			}
+			switch {
+			case line == "":
+				line = word
+			case f.width(line+" "+word, size) <= maxWidth:
+				line += " " + word
+			default:
+				lines = append(lines, line)
+				line = word
+			}
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+type pdfDoc struct {
+	width, height float64
+	pages         []*bytes.Buffer
+	cur           *bytes.Buffer
+}
+
+func newPDF(width, height float64) *pdfDoc {
+	return &pdfDoc{width: width, height: height}
+}
+
+func (d *pdfDoc) addPage() {
+	d.cur = &bytes.Buffer{}
+	d.pages = append(d.pages, d.cur)
+}
+
+// usePage pindah ke halaman yang sudah ada (index 0), misal untuk menulis nomor halaman
+func (d *pdfDoc) usePage(i int) {
+	d.cur = d.pages[i]
+}
+
+func (d *pdfDoc) pageCount() int {
+	return len(d.pages)
+}
+
+// text menulis satu baris teks, y adalah garis dasar dari atas halaman
+func (d *pdfDoc) text(x, y float64, font pdfFont, size float64, s string) {
+	fmt.Fprintf(d.cur, "BT /%s %s Tf %s %s Td (%s) Tj ET\n",
+		font.resource, pdfNum(size), pdfNum(x), pdfNum(d.height-y), pdfEscape(pdfEncode(s)))
+}
+
+// textRight menulis teks rata kanan di x
+func (d *pdfDoc) textRight(x, y float64, font pdfFont, size float64, s string) {
+	d.text(x-font.width(s, size), y, font, size, s)
+}
+
+// textCenter menulis teks di tengah x
+func (d *pdfDoc) textCenter(x, y float64, font pdfFont, size float64, s string) {
+	d.text(x-font.width(s, size)/2, y, font, size, s)
+}
+
+func (d *pdfDoc) line(x1, y1, x2, y2, width float64) {
+	fmt.Fprintf(d.cur, "%s w %s %s m %s %s l S\n",
+		pdfNum(width), pdfNum(x1), pdfNum(d.height-y1), pdfNum(x2), pdfNum(d.height-y2))
+}
+
+// rect menggambar kotak dengan garis tipis, fill = tingkat abu-abu isi (0 hitam, 1 putih), negatif tanpa isi
+func (d *pdfDoc) rect(x, y, w, h, fill float64) {
+	op := "S"
+	if fill >= 0 {
+		fmt.Fprintf(d.cur, "%s g ", pdfNum(fill))
+		op = "B"
+	}
+	fmt.Fprintf(d.cur, "0.5 w %s %s %s %s re %s 0 g\n", pdfNum(x), pdfNum(d.height-y-h), pdfNum(w), pdfNum(h), op)
+}
+
+// write menulis dokumen: catalog, pages, 2 font, lalu page + content stream per halaman
+func (d *pdfDoc) write(w io.Writer) error {
+	var b bytes.Buffer
+	var offsets []int
+	obj := func(body string) {
+		offsets = append(offsets, b.Len())
+		fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	b.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	const firstPage = 5 // objek 1-4: catalog, pages, font F1, font F2
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", firstPage+i*2)
+	}
+	obj("<< /Type /Catalog /Pages 2 0 R >>")
+	obj(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	for _, f := range []pdfFont{pdfHelvetica, pdfHelveticaBold} {
+		obj(fmt.Sprintf("<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>", f.base))
+	}
+
+	for i, page := range d.pages {
+		obj(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %s %s] "+
+			"/Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			pdfNum(d.width), pdfNum(d.height), firstPage+i*2+1))
+
+		var z bytes.Buffer
+		zw := zlib.NewWriter(&z)
+		zw.Write(page.Bytes())
+		if err := zw.Close(); err != nil {
+			return fmt.Errorf("pdf halaman %d: %w", i+1, err)
+		}
+		obj(fmt.Sprintf("<< /Length %d /Filter /FlateDecode >>\nstream\n%s\nendstream", z.Len(), z.Bytes()))
+	}
+
+	xref := b.Len()
+	fmt.Fprintf(&b, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&b, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&b, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+func pdfNum(v float64) string {
+	return strconv.FormatFloat(v, 'f', 2, 64)
+}
+
+// pdfEncode mengubah UTF-8 ke WinAnsiEncoding, karakter di luar itu jadi "?"
+func pdfEncode(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '\t':
+			b.WriteByte(' ')
+		case r < 32:
+		case r < 127, r >= 160 && r <= 255:
+			b.WriteByte(byte(r))
+		default:
+			if c, ok := winAnsiExtra[r]; ok {
+				b.WriteByte(c)
+			} else {
+				b.WriteByte('?')
+			}
+		}
+	}
+	return b.String()
+}
+
+func pdfEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `(`, `\(`, `)`, `\)`).Replace(s)
+}
+
+// karakter WinAnsi di 128-159 yang sering muncul dari teks hasil copy dokumen
+var winAnsiExtra = map[rune]byte{
+	'€': 0x80, '…': 0x85, '‘': 0x91, '’': 0x92, '“': 0x93, '”': 0x94,
+	'•': 0x95, '–': 0x96, '—': 0x97, '™': 0x99,
+}
+
+// lebar glyph karakter 32-126 dari AFM Helvetica dan Helvetica-Bold (per 1000 unit)
+var helveticaWidths = [95]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+}
+
+var helveticaBoldWidths = [95]int{
+	278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+	975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+	333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+	611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"net/url"
+	"strconv"
+)
+
+const (
+	// ukuran halaman jika ?page= dikirim tanpa ?size=
+	defaultPageSize = 50
+	maxPageSize     = 1000
+)
+
+// Meta keterangan jumlah data dan halaman di response list
+type Meta struct {
+	Total      int `json:"total"`
+	Page       int `json:"page,omitempty"`
+	Size       int `json:"size,omitempty"`
+	TotalPages int `json:"total_pages,omitempty"`
+}
+
+func newMeta(q PokinQuery, total int) *Meta {
+	meta := &Meta{Total: total}
+	if q.Size > 0 {
+		meta.Page = q.Page
+		meta.Size = q.Size
+		meta.TotalPages = (total + q.Size - 1) / q.Size
+	}
+	return meta
+}
+
+// parsePokinQuery membaca filter (kode_opd, jenis_pohon, kode_program_unggulan, status, nama_pohon),
+// sort dan halaman (page, size) laporan. Tanpa page dan size semua baris dikirim.
+func parsePokinQuery(values url.Values) (PokinQuery, []FieldError) {
+	var fields []FieldError
+	q := PokinQuery{
+		KodeOpd:             values.Get("kode_opd"),
+		JenisPohon:          values.Get("jenis_pohon"),
+		KodeProgramUnggulan: values.Get("kode_program_unggulan"),
+		Status:              values.Get("status"),
+		NamaPohon:           values.Get("nama_pohon"),
+	}
+
+	sort, err := parseSort(values.Get("sort"))
+	if err != nil {
+		fields = append(fields, FieldError{Field: "sort", Message: err.Error()})
+	}
+	q.Sort = sort
+
+	pageStr, sizeStr := values.Get("page"), values.Get("size")
+	if pageStr != "" {
+		page, err := strconv.Atoi(pageStr)
+		if err != nil || page < 1 {
+			fields = append(fields, FieldError{Field: "page", Message: "harus angka mulai dari 1"})
+		}
+		q.Page = page
+		q.Size = defaultPageSize
+	}
+	if sizeStr != "" {
+		size, err := strconv.Atoi(sizeStr)
+		if err != nil || size < 1 || size > maxPageSize {
+			fields = append(fields, FieldError{Field: "size", Message: "harus angka 1 sampai " + strconv.Itoa(maxPageSize)})
+		}
+		q.Size = size
+		if q.Page == 0 {
+			q.Page = 1
+		}
+	}
+
+	return q, fields
+}
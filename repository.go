@@ -0,0 +1,230 @@
+package main
+
+import (
+	"context"
+	"slices"
+	"strings"
+)
+
+// TaggingRepository membungkus semua query baca yang dipakai handler laporan tagging.
+// Implementasi utama ada di mysqlRepository, handler hanya bergantung ke interface ini
+// sehingga logic laporan bisa dijalankan dengan data palsu (fake).
+// Semua method menerima context request, query dibatalkan saat client putus atau deadline habis.
+type TaggingRepository interface {
+	// list pokin yang di tagging dengan nama_tagging pada tahun tertentu, sudah difilter,
+	// diurutkan dan dipotong per halaman sesuai q, beserta total baris sebelum dipotong
+	GetPokinByTagging(ctx context.Context, namaTagging string, tahun int, q PokinQuery) ([]Pokin, int, error)
+	// pokin id -> pelaksana beserta rencana kinerja. Jika hanya pelengkap rekin (indikator program,
+	// tahapan pelaksanaan) yang gagal, hasil tetap dikembalikan bersama *PartialError
+	GetRencanaKinerjaByIdPokins(ctx context.Context, req []IdPokinsJenisPohon, tahun int) (map[int][]PelaksanaPokin, error)
+	// pokin id -> indikator beserta target
+	GetIndikatorPokinByIdPokins(ctx context.Context, idPokins []int) (map[int][]IndikatorPohon, error)
+	// rekin id -> total pagu
+	GetPaguByPokinIds(ctx context.Context, idPokins []int) (map[string]Pagu, error)
+	// rekin id -> total pagu
+	GetPaguByRekinIds(ctx context.Context, idRekins []string) (map[string]Pagu, error)
+	// pokin yang di tagging ke satu kode program unggulan
+	GetDetailByKodeProgramUnggulan(ctx context.Context, kode string) ([]Pokin, error)
+	// pokin, pelaksana dan rekin yang di tagging ke beberapa kode program unggulan
+	GetDetailBatchByKodeProgramUnggulan(ctx context.Context, kodes []string) ([]Pokin, error)
+	// pokin id -> bidang urusan dan program dari pokin operational di bawahnya (sampai 2 level)
+	GetBidangUrusanProgramByIdPokins(ctx context.Context, idPokins []int) (map[int]BidangUrusanProgram, error)
+	// rekin id -> rencana aksi beserta bobot 12 bulan, urut id rencana aksi
+	GetRencanaAksiByRekinIds(ctx context.Context, idRekins []string) (map[string][]RencanaAksi, error)
+}
+
+// PartialError langkah pelengkap di dalam repository yang gagal, hasil method tetap bisa dipakai
+// tanpa data langkah ini. Beberapa PartialError digabung dengan errors.Join.
+type PartialError struct {
+	Step    string
+	Message string
+	Err     error
+}
+
+func (e *PartialError) Error() string { return e.Step + ": " + e.Err.Error() }
+
+func (e *PartialError) Unwrap() error { return e.Err }
+
+// partialErrors semua PartialError di err, nil jika ada error lain sehingga hasil tidak bisa dipakai
+func partialErrors(err error) []*PartialError {
+	if pe, ok := err.(*PartialError); ok {
+		return []*PartialError{pe}
+	}
+	joined, ok := err.(interface{ Unwrap() []error })
+	if !ok {
+		return nil
+	}
+	var all []*PartialError
+	for _, e := range joined.Unwrap() {
+		inner := partialErrors(e)
+		if inner == nil {
+			return nil
+		}
+		all = append(all, inner...)
+	}
+	return all
+}
+
+// PokinQuery filter, urutan dan halaman laporan tagging. Field kosong = tanpa filter.
+type PokinQuery struct {
+	KodeOpd             string
+	JenisPohon          string
+	KodeProgramUnggulan string
+	Status              string
+	// cari sebagian nama pohon, tidak membedakan huruf besar/kecil
+	NamaPohon string
+	Sort      PokinSort
+	// halaman mulai dari 1, Size 0 = semua baris
+	Page int
+	Size int
+}
+
+// match dipakai implementasi yang memfilter di memori, harus sama dengan WHERE di MySQL
+func (q PokinQuery) match(p Pokin) bool {
+	return (q.KodeOpd == "" || p.KodeOpd == q.KodeOpd) &&
+		(q.JenisPohon == "" || string(p.JenisPohon) == q.JenisPohon) &&
+		(q.KodeProgramUnggulan == "" || p.KodeProgramUnggulan == q.KodeProgramUnggulan) &&
+		(q.Status == "" || p.Status == q.Status) &&
+		(q.NamaPohon == "" || strings.Contains(strings.ToLower(p.NamaPohon), strings.ToLower(q.NamaPohon)))
+}
+
+// offset baris pertama halaman
+func (q PokinQuery) offset() int {
+	if q.Size <= 0 || q.Page <= 1 {
+		return 0
+	}
+	return (q.Page - 1) * q.Size
+}
+
+// paginate memotong list yang sudah urut sesuai halaman
+func (q PokinQuery) paginate(list []Pokin) []Pokin {
+	if q.Size <= 0 {
+		return list
+	}
+	start := min(q.offset(), len(list))
+	end := min(start+q.Size, len(list))
+	return list[start:end]
+}
+
+// BidangUrusanProgram nilai unik bidang urusan dan program untuk satu pokin, urut kode
+type BidangUrusanProgram struct {
+	BidangUrusans []BidangUrusan
+	Programs      []Program
+}
+
+// add menambah bidang urusan dan program jika belum ada, kode kosong diabaikan
+func (bp *BidangUrusanProgram) add(bidur BidangUrusan, prg Program) {
+	if bidur.KodeBidangUrusan != "" && !slices.ContainsFunc(bp.BidangUrusans, func(b BidangUrusan) bool {
+		return b.KodeBidangUrusan == bidur.KodeBidangUrusan
+	}) {
+		bp.BidangUrusans = append(bp.BidangUrusans, bidur)
+	}
+	if prg.KodeProgram != "" && !slices.ContainsFunc(bp.Programs, func(p Program) bool {
+		return p.KodeProgram == prg.KodeProgram
+	}) {
+		bp.Programs = append(bp.Programs, prg)
+	}
+}
+
+func (bp *BidangUrusanProgram) sortByKode() {
+	slices.SortFunc(bp.BidangUrusans, func(a, b BidangUrusan) int {
+		return strings.Compare(a.KodeBidangUrusan, b.KodeBidangUrusan)
+	})
+	slices.SortFunc(bp.Programs, func(a, b Program) int {
+		return strings.Compare(a.KodeProgram, b.KodeProgram)
+	})
+}
+
+// applyBidangUrusanProgram mengisi bidang urusan dan program rekin yang masih kosong.
+// Rekin dengan subkegiatan memakai nilai yang kodenya cocok dengan subkegiatan,
+// rekin tanpa subkegiatan (strategic/tactical) cocok dengan semua nilai.
+// Kode dan nama tetap satu nilai: jika cocok lebih dari satu diisi "-",
+// daftar lengkapnya ada di BidangUrusans dan Programs.
+func applyBidangUrusanProgram(rekin *RencanaKinerjaAsn, bp BidangUrusanProgram) {
+	if rekin.KodeBidangUrusan == "" || rekin.KodeBidangUrusan == "-" {
+		var matched []BidangUrusan
+		for _, b := range bp.BidangUrusans {
+			if rekin.KodeSubkegiatan == "" || strings.HasPrefix(rekin.KodeSubkegiatan, b.KodeBidangUrusan+".") {
+				matched = append(matched, b)
+			}
+		}
+		switch len(matched) {
+		case 0:
+		case 1:
+			rekin.KodeBidangUrusan = matched[0].KodeBidangUrusan
+			rekin.NamaBidangUrusan = matched[0].NamaBidangUrusan
+		default:
+			rekin.KodeBidangUrusan, rekin.NamaBidangUrusan = "-", "-"
+		}
+		rekin.BidangUrusans = matched
+	}
+
+	if rekin.KodeProgram == "" || rekin.KodeProgram == "-" {
+		var matched []Program
+		for _, p := range bp.Programs {
+			if rekin.KodeSubkegiatan == "" || strings.HasPrefix(rekin.KodeSubkegiatan, p.KodeProgram+".") {
+				matched = append(matched, p)
+			}
+		}
+		switch len(matched) {
+		case 0:
+		case 1:
+			rekin.KodeProgram = matched[0].KodeProgram
+			rekin.NamaProgram = matched[0].NamaProgram
+		default:
+			rekin.KodeProgram, rekin.NamaProgram = "-", "-"
+		}
+		rekin.Programs = matched
+	}
+}
+
+type IdPokinsJenisPohon struct {
+	idPokin    int
+	jenisPohon string
+}
+
+// newBobotBulanan deret bobot bulan 1-12 yang masih nol
+func newBobotBulanan() []BulanBobot {
+	series := make([]BulanBobot, 12)
+	for i := range series {
+		series[i].Bulan = i + 1
+	}
+	return series
+}
+
+// addBobot menambah bobot bulan (1-12) ke rencana aksi, deret bulanan dan triwulannya
+func (ra *RencanaAksi) addBobot(bulan, bobot int) {
+	if bulan < 1 || bulan > 12 {
+		return
+	}
+	if ra.BobotBulanan == nil {
+		ra.BobotBulanan = newBobotBulanan()
+	}
+	ra.BobotBulanan[bulan-1].Bobot += bobot
+	ra.TahapanPelaksanaan.addBobot(bulan, bobot)
+}
+
+// applyRencanaAksi mengisi rencana aksi rekin dan bobot bulanan gabungannya
+func applyRencanaAksi(rekin *RencanaKinerjaAsn, aksis []RencanaAksi) {
+	rekin.RencanaAksis = aksis
+	rekin.BobotBulanan = newBobotBulanan()
+	for _, ra := range aksis {
+		for i, bb := range ra.BobotBulanan {
+			rekin.BobotBulanan[i].Bobot += bb.Bobot
+		}
+	}
+}
+
+// addBobot menambah bobot bulan (1-12) ke triwulan yang sesuai, bulan di luar itu diabaikan
+func (wp *WaktuPelaksanaan) addBobot(bulan, bobot int) {
+	switch {
+	case bulan >= 1 && bulan <= 3:
+		wp.Tw1 += bobot
+	case bulan >= 4 && bulan <= 6:
+		wp.Tw2 += bobot
+	case bulan >= 7 && bulan <= 9:
+		wp.Tw3 += bobot
+	case bulan >= 10 && bulan <= 12:
+		wp.Tw4 += bobot
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"time"
+)
+
+// instrumentedRepository mencatat latency dan error tiap fungsi repository ke /metrics
+type instrumentedRepository struct {
+	next    TaggingRepository
+	metrics *metrics
+}
+
+func newInstrumentedRepository(next TaggingRepository, m *metrics) TaggingRepository {
+	return &instrumentedRepository{next: next, metrics: m}
+}
+
+func (r *instrumentedRepository) GetPokinByTagging(ctx context.Context, namaTagging string, tahun int, q PokinQuery) ([]Pokin, int, error) {
+	start := time.Now()
+	res, total, err := r.next.GetPokinByTagging(ctx, namaTagging, tahun, q)
+	r.metrics.observeQuery("GetPokinByTagging", start, err)
+	return res, total, err
+}
+
+func (r *instrumentedRepository) GetRencanaKinerjaByIdPokins(ctx context.Context, req []IdPokinsJenisPohon, tahun int) (map[int][]PelaksanaPokin, error) {
+	start := time.Now()
+	res, err := r.next.GetRencanaKinerjaByIdPokins(ctx, req, tahun)
+	r.metrics.observeQuery("GetRencanaKinerjaByIdPokins", start, err)
+	return res, err
+}
+
+func (r *instrumentedRepository) GetIndikatorPokinByIdPokins(ctx context.Context, idPokins []int) (map[int][]IndikatorPohon, error) {
+	start := time.Now()
+	res, err := r.next.GetIndikatorPokinByIdPokins(ctx, idPokins)
+	r.metrics.observeQuery("GetIndikatorPokinByIdPokins", start, err)
+	return res, err
+}
+
+func (r *instrumentedRepository) GetPaguByPokinIds(ctx context.Context, idPokins []int) (map[string]Pagu, error) {
+	start := time.Now()
+	res, err := r.next.GetPaguByPokinIds(ctx, idPokins)
+	r.metrics.observeQuery("GetPaguByPokinIds", start, err)
+	return res, err
+}
+
+func (r *instrumentedRepository) GetPaguByRekinIds(ctx context.Context, idRekins []string) (map[string]Pagu, error) {
+	start := time.Now()
+	res, err := r.next.GetPaguByRekinIds(ctx, idRekins)
+	r.metrics.observeQuery("GetPaguByRekinIds", start, err)
+	return res, err
+}
+
+func (r *instrumentedRepository) GetDetailByKodeProgramUnggulan(ctx context.Context, kode string) ([]Pokin, error) {
+	start := time.Now()
+	res, err := r.next.GetDetailByKodeProgramUnggulan(ctx, kode)
+	r.metrics.observeQuery("GetDetailByKodeProgramUnggulan", start, err)
+	return res, err
+}
+
+func (r *instrumentedRepository) GetDetailBatchByKodeProgramUnggulan(ctx context.Context, kodes []string) ([]Pokin, error) {
+	start := time.Now()
+	res, err := r.next.GetDetailBatchByKodeProgramUnggulan(ctx, kodes)
+	r.metrics.observeQuery("GetDetailBatchByKodeProgramUnggulan", start, err)
+	return res, err
+}
+
+func (r *instrumentedRepository) GetBidangUrusanProgramByIdPokins(ctx context.Context, idPokins []int) (map[int]BidangUrusanProgram, error) {
+	start := time.Now()
+	res, err := r.next.GetBidangUrusanProgramByIdPokins(ctx, idPokins)
+	r.metrics.observeQuery("GetBidangUrusanProgramByIdPokins", start, err)
+	return res, err
+}
+
+func (r *instrumentedRepository) GetRencanaAksiByRekinIds(ctx context.Context, idRekins []string) (map[string][]RencanaAksi, error) {
+	start := time.Now()
+	res, err := r.next.GetRencanaAksiByRekinIds(ctx, idRekins)
+	r.metrics.observeQuery("GetRencanaAksiByRekinIds", start, err)
+	return res, err
+}
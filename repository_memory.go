@@ -0,0 +1,773 @@
+package main
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Fixture berisi baris-baris tabel perencanaan yang dibutuhkan laporan tagging.
+// Nama field mengikuti nama kolom di database supaya mudah disalin dari hasil query.
+type Fixture struct {
+	Opd               []FixtureOpd               `json:"operasional_daerah"`
+	PohonKinerja      []FixturePokin             `json:"pohon_kinerja"`
+	Tagging           []FixtureTagging           `json:"tagging_pokin"`
+	KeteranganTagging []FixtureKeteranganTagging `json:"keterangan_tagging_program_unggulan"`
+	ProgramUnggulan   []FixtureProgramUnggulan   `json:"program_unggulan"`
+	// tabel master tema tagging lain (datamaster_rb, dst), nama tabel -> baris kolom:nilai
+	MasterTables    map[string][]map[string]any `json:"master_tables"`
+	Pegawai         []FixturePegawai            `json:"pegawai"`
+	PelaksanaPokin  []FixturePelaksanaPokin     `json:"pelaksana_pokin"`
+	RencanaKinerja  []FixtureRencanaKinerja     `json:"rencana_kinerja"`
+	Subkegiatan     []Subkegiatan               `json:"subkegiatan"`
+	BidangUrusan    []BidangUrusan              `json:"bidang_urusan"`
+	MasterProgram   []FixtureProgram            `json:"master_program"`
+	IndikatorMatrix []FixtureIndikatorMatrix    `json:"indikator_matrix"`
+	Indikator       []FixtureIndikator          `json:"indikator"`
+	Target          []FixtureTarget             `json:"target"`
+	RencanaAksi     []FixtureRencanaAksi        `json:"rencana_aksi"`
+	// bobot bulanan rencana aksi (tb_pelaksanaan_rencana_aksi)
+	PelaksanaanRencanaAksi []FixturePelaksanaanRencanaAksi `json:"pelaksanaan_rencana_aksi"`
+	RincianBelanja         []FixtureRincianBelanja         `json:"rincian_belanja"`
+}
+
+type FixtureOpd struct {
+	KodeOpd string `json:"kode_opd"`
+	NamaOpd string `json:"nama_opd"`
+}
+
+type FixturePokin struct {
+	Id         int    `json:"id"`
+	Parent     int    `json:"parent"`
+	NamaPohon  string `json:"nama_pohon"`
+	Tahun      int    `json:"tahun"`
+	JenisPohon string `json:"jenis_pohon"`
+	KodeOpd    string `json:"kode_opd"`
+	Status     string `json:"status"`
+	Keterangan string `json:"keterangan"`
+}
+
+type FixtureTagging struct {
+	Id                int    `json:"id"`
+	IdPokin           int    `json:"id_pokin"`
+	NamaTagging       string `json:"nama_tagging"`
+	KeteranganTagging string `json:"keterangan_tagging"`
+}
+
+type FixtureKeteranganTagging struct {
+	IdTagging           int    `json:"id_tagging"`
+	KodeProgramUnggulan string `json:"kode_program_unggulan"`
+}
+
+type FixtureProgramUnggulan struct {
+	Id                        int    `json:"id"`
+	KodeProgramUnggulan       string `json:"kode_program_unggulan"`
+	NamaTagging               string `json:"nama_tagging"`
+	KeteranganProgramUnggulan string `json:"keterangan_program_unggulan"`
+}
+
+type FixturePegawai struct {
+	Id   string `json:"id"`
+	Nip  string `json:"nip"`
+	Nama string `json:"nama"`
+}
+
+type FixturePelaksanaPokin struct {
+	PohonKinerjaId int    `json:"pohon_kinerja_id"`
+	PegawaiId      string `json:"pegawai_id"`
+}
+
+type FixtureRencanaKinerja struct {
+	Id                 string `json:"id"`
+	IdPohon            int    `json:"id_pohon"`
+	NamaRencanaKinerja string `json:"nama_rencana_kinerja"`
+	PegawaiId          string `json:"pegawai_id"`
+	KodeOpd            string `json:"kode_opd"`
+	Tahun              int    `json:"tahun"`
+	Catatan            string `json:"catatan"`
+	KodeSubkegiatan    string `json:"kode_subkegiatan"`
+}
+
+type FixtureProgram struct {
+	KodeProgram string `json:"kode_program"`
+	NamaProgram string `json:"nama_program"`
+}
+
+type FixtureIndikatorMatrix struct {
+	Kode          string `json:"kode"`
+	KodeOpd       string `json:"kode_opd"`
+	Tahun         int    `json:"tahun"`
+	KodeIndikator string `json:"kode_indikator"`
+	Indikator     string `json:"indikator"`
+}
+
+type FixtureIndikator struct {
+	Id        string `json:"id"`
+	PokinId   int    `json:"pokin_id"`
+	Indikator string `json:"indikator"`
+}
+
+type FixtureTarget struct {
+	Id          string `json:"id"`
+	IndikatorId string `json:"indikator_id"`
+	Target      string `json:"target"`
+	Satuan      string `json:"satuan"`
+	Tahun       int    `json:"tahun"`
+}
+
+type FixtureRencanaAksi struct {
+	Id               string `json:"id"`
+	RencanaKinerjaId string `json:"rencana_kinerja_id"`
+}
+
+type FixturePelaksanaanRencanaAksi struct {
+	RencanaAksiId string `json:"rencana_aksi_id"`
+	Bulan         int    `json:"bulan"`
+	Bobot         int    `json:"bobot"`
+}
+
+type FixtureRincianBelanja struct {
+	RenaksiId string `json:"renaksi_id"`
+	Anggaran  int64  `json:"anggaran"`
+}
+
+// fixture bawaan untuk mode demo
+//
+//go:embed fixtures/demo.json
+var demoFixture []byte
+
+// LoadFixture membaca fixture JSON dari file
+func LoadFixture(path string) (*Fixture, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("baca fixture %s: %w", path, err)
+	}
+	return ParseFixture(data)
+}
+
+func ParseFixture(data []byte) (*Fixture, error) {
+	var fx Fixture
+	if err := json.Unmarshal(data, &fx); err != nil {
+		return nil, fmt.Errorf("parse fixture: %w", err)
+	}
+	return &fx, nil
+}
+
+// memoryRepository menjalankan query laporan di atas data fixture,
+// dipakai untuk mode demo dan fake di luar database.
+type memoryRepository struct {
+	fx   *Fixture
+	tags *TagRegistry
+
+	opdByKode       map[string]FixtureOpd
+	pokinById       map[int]FixturePokin
+	pegawaiByNip    map[string]FixturePegawai
+	pegawaiById     map[string]FixturePegawai
+	subByKode       map[string]Subkegiatan
+	bidurByKode     map[string]BidangUrusan
+	childrenById    map[int][]int
+	programByKode   map[string]FixtureProgram
+	prungByKode     map[string]FixtureProgramUnggulan
+	masterTables    map[string][]map[string]any
+	taggingById     map[int]FixtureTagging
+	targetByIndId   map[string][]FixtureTarget
+	renaksiByRekin  map[string][]FixtureRencanaAksi
+	rinbelByRenaksi map[string][]FixtureRincianBelanja
+	bobotByRenaksi  map[string][]FixturePelaksanaanRencanaAksi
+}
+
+func NewMemoryRepository(fx *Fixture, tags *TagRegistry) TaggingRepository {
+	r := &memoryRepository{
+		fx:              fx,
+		tags:            tags,
+		opdByKode:       make(map[string]FixtureOpd),
+		pokinById:       make(map[int]FixturePokin),
+		pegawaiByNip:    make(map[string]FixturePegawai),
+		pegawaiById:     make(map[string]FixturePegawai),
+		subByKode:       make(map[string]Subkegiatan),
+		bidurByKode:     make(map[string]BidangUrusan),
+		childrenById:    make(map[int][]int),
+		programByKode:   make(map[string]FixtureProgram),
+		prungByKode:     make(map[string]FixtureProgramUnggulan),
+		masterTables:    make(map[string][]map[string]any),
+		taggingById:     make(map[int]FixtureTagging),
+		targetByIndId:   make(map[string][]FixtureTarget),
+		renaksiByRekin:  make(map[string][]FixtureRencanaAksi),
+		rinbelByRenaksi: make(map[string][]FixtureRincianBelanja),
+		bobotByRenaksi:  make(map[string][]FixturePelaksanaanRencanaAksi),
+	}
+
+	for _, o := range fx.Opd {
+		r.opdByKode[o.KodeOpd] = o
+	}
+	for _, p := range fx.PohonKinerja {
+		r.pokinById[p.Id] = p
+		r.childrenById[p.Parent] = append(r.childrenById[p.Parent], p.Id)
+	}
+	for _, p := range fx.Pegawai {
+		r.pegawaiByNip[p.Nip] = p
+		r.pegawaiById[p.Id] = p
+	}
+	for _, s := range fx.Subkegiatan {
+		r.subByKode[s.KodeSubkegiatan] = s
+	}
+	for _, b := range fx.BidangUrusan {
+		r.bidurByKode[b.KodeBidangUrusan] = b
+	}
+	for _, p := range fx.MasterProgram {
+		r.programByKode[p.KodeProgram] = p
+	}
+	for table, rows := range fx.MasterTables {
+		r.masterTables[table] = rows
+	}
+	for _, p := range fx.ProgramUnggulan {
+		r.prungByKode[p.KodeProgramUnggulan] = p
+		r.masterTables["tb_program_unggulan"] = append(r.masterTables["tb_program_unggulan"], map[string]any{
+			"id":                          p.Id,
+			"kode_program_unggulan":       p.KodeProgramUnggulan,
+			"nama_tagging":                p.NamaTagging,
+			"keterangan_program_unggulan": p.KeteranganProgramUnggulan,
+		})
+	}
+	for _, t := range fx.Tagging {
+		r.taggingById[t.Id] = t
+	}
+	for _, t := range fx.Target {
+		r.targetByIndId[t.IndikatorId] = append(r.targetByIndId[t.IndikatorId], t)
+	}
+	for _, ra := range fx.RencanaAksi {
+		r.renaksiByRekin[ra.RencanaKinerjaId] = append(r.renaksiByRekin[ra.RencanaKinerjaId], ra)
+	}
+	for _, rb := range fx.RincianBelanja {
+		r.rinbelByRenaksi[rb.RenaksiId] = append(r.rinbelByRenaksi[rb.RenaksiId], rb)
+	}
+	for _, pl := range fx.PelaksanaanRencanaAksi {
+		r.bobotByRenaksi[pl.RencanaAksiId] = append(r.bobotByRenaksi[pl.RencanaAksiId], pl)
+	}
+
+	return r
+}
+
+func (r *memoryRepository) GetPokinByTagging(ctx context.Context, namaTagging string, tahun int, q PokinQuery) ([]Pokin, int, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	master := r.tags.Lookup(namaTagging).Master()
+
+	var listPokin []Pokin
+	for _, tag := range r.fx.Tagging {
+		if tag.NamaTagging != namaTagging {
+			continue
+		}
+		pokin, ok := r.pokinById[tag.IdPokin]
+		if !ok || pokin.Tahun != tahun || pokin.KodeOpd == "" {
+			continue
+		}
+		if pokin.Status != "pokin dari pemda" && pokin.Status != "" {
+			continue
+		}
+		opd, ok := r.opdByKode[pokin.KodeOpd]
+		if !ok {
+			continue
+		}
+
+		for _, ket := range r.fx.KeteranganTagging {
+			if ket.IdTagging != tag.Id {
+				continue
+			}
+
+			for _, row := range r.masterTables[master.Table] {
+				if masterValue(row, master.JoinColumn) != ket.KodeProgramUnggulan {
+					continue
+				}
+
+				idProgramUnggulan, _ := strconv.Atoi(masterValue(row, master.IdColumn))
+				listPokin = append(listPokin, Pokin{
+					IdProgramUnggulan:   idProgramUnggulan,
+					KodeProgramUnggulan: masterColumn(row, master.KodeColumn),
+					NamaProgramUnggulan: masterColumn(row, master.NamaColumn),
+					RencanaImplementasi: masterColumn(row, master.DeskripsiColumn),
+					IdPohon:             pokin.Id,
+					NamaPohon:           pokin.NamaPohon,
+					Tahun:               Tahun(pokin.Tahun),
+					JenisPohon:          JenisPohon(pokin.JenisPohon),
+					KodeOpd:             pokin.KodeOpd,
+					NamaOpd:             opd.NamaOpd,
+					KeteranganTagging:   tag.KeteranganTagging,
+					Status:              pokin.Status,
+					Keterangan:          pokin.Keterangan,
+				})
+			}
+		}
+	}
+
+	var filtered []Pokin
+	for _, p := range listPokin {
+		if q.match(p) {
+			filtered = append(filtered, p)
+		}
+	}
+	sortPokinsBy(filtered, q.Sort)
+
+	return q.paginate(filtered), len(filtered), nil
+}
+
+// nilai kolom master sebagai string, angka JSON ditulis tanpa desimal
+func masterValue(row map[string]any, col string) string {
+	switch v := row[col].(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// sama dengan TagMaster.selectColumn: '-' jika kolom tidak dikonfigurasi
+func masterColumn(row map[string]any, col string) string {
+	if col == "" {
+		return "-"
+	}
+	return masterValue(row, col)
+}
+
+func (r *memoryRepository) GetRencanaKinerjaByIdPokins(ctx context.Context, req []IdPokinsJenisPohon, tahun int) (map[int][]PelaksanaPokin, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	result := make(map[int][]PelaksanaPokin)
+
+	if len(req) == 0 {
+		return result, nil
+	}
+
+	var idPokins []int
+	for _, rq := range req {
+		idPokins = append(idPokins, rq.idPokin)
+	}
+
+	paguMap, err := r.GetPaguByPokinIds(ctx, idPokins)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, pokinId := range uniqueInts(idPokins) {
+		pokin, ok := r.pokinById[pokinId]
+		if !ok {
+			continue
+		}
+
+		var pelaksanas []PelaksanaPokin
+		for _, rk := range r.fx.RencanaKinerja {
+			if rk.IdPohon != pokin.Id || rk.KodeOpd != pokin.KodeOpd {
+				continue
+			}
+			pegawai, ok := r.pegawaiByNip[rk.PegawaiId]
+			if !ok {
+				continue
+			}
+
+			rekin := RencanaKinerjaAsn{
+				IdRekin:        rk.Id,
+				RencanaKinerja: rk.NamaRencanaKinerja,
+				NamaPelaksana:  pegawai.Nama,
+				NIPPelaksana:   pegawai.Nip,
+				Catatan:        rk.Catatan,
+				Pagu:           paguMap[rk.Id],
+			}
+
+			if sub, ok := r.subByKode[rk.KodeSubkegiatan]; ok {
+				rekin.KodeSubkegiatan = sub.KodeSubkegiatan
+				rekin.NamaSubkegiatan = sub.NamaSubkegiatan
+			}
+			if prg, ok := r.programByKode[kodeProgramFromSubkegiatan(rk.KodeSubkegiatan)]; ok {
+				rekin.KodeProgram = prg.KodeProgram
+				rekin.NamaProgram = prg.NamaProgram
+				rekin.IndikatorPrograms = r.indikatorProgram(prg.KodeProgram, rk.KodeOpd, tahun)
+			}
+			rekin.TahapanPelaksanaan = r.tahapanPelaksanaan(rk.Id)
+
+			idx := -1
+			for i := range pelaksanas {
+				if pelaksanas[i].NIPPelaksana == rekin.NIPPelaksana {
+					idx = i
+					break
+				}
+			}
+			if idx < 0 {
+				pelaksanas = append(pelaksanas, PelaksanaPokin{
+					IdPelaksana:   pegawai.Id,
+					NamaPelaksana: rekin.NamaPelaksana,
+					NIPPelaksana:  rekin.NIPPelaksana,
+				})
+				idx = len(pelaksanas) - 1
+			}
+			pelaksanas[idx].RencanaKinerjas = append(pelaksanas[idx].RencanaKinerjas, rekin)
+		}
+
+		if len(pelaksanas) > 0 {
+			result[pokinId] = pelaksanas
+		}
+	}
+
+	return result, nil
+}
+
+// bobot rencana aksi satu rekin dijumlah per triwulan
+func (r *memoryRepository) tahapanPelaksanaan(idRekin string) WaktuPelaksanaan {
+	var result WaktuPelaksanaan
+	for _, ra := range r.renaksiByRekin[idRekin] {
+		for _, pl := range r.bobotByRenaksi[ra.Id] {
+			result.addBobot(pl.Bulan, pl.Bobot)
+		}
+	}
+	return result
+}
+
+func (r *memoryRepository) indikatorProgram(kodeProgram, kodeOpd string, tahun int) []IndikatorProgram {
+	var matrix []FixtureIndikatorMatrix
+	for _, im := range r.fx.IndikatorMatrix {
+		if im.Kode == kodeProgram && im.KodeOpd == kodeOpd && im.Tahun == tahun {
+			matrix = append(matrix, im)
+		}
+	}
+	sort.SliceStable(matrix, func(i, j int) bool {
+		return matrix[i].KodeIndikator < matrix[j].KodeIndikator
+	})
+
+	indikators := make([]IndikatorProgram, 0, len(matrix))
+	for _, im := range matrix {
+		indikators = append(indikators, IndikatorProgram{Indikator: im.Indikator})
+	}
+	return indikators
+}
+
+func (r *memoryRepository) GetIndikatorPokinByIdPokins(ctx context.Context, idPokins []int) (map[int][]IndikatorPohon, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	result := make(map[int][]IndikatorPohon)
+
+	wanted := make(map[int]bool, len(idPokins))
+	for _, id := range idPokins {
+		wanted[id] = true
+	}
+
+	for _, ind := range r.fx.Indikator {
+		if !wanted[ind.PokinId] {
+			continue
+		}
+
+		newInd := IndikatorPohon{
+			IdIndikator: ind.Id,
+			IdPokin:     strconv.Itoa(ind.PokinId),
+			Indikator:   ind.Indikator,
+			Target:      []TargetIndikator{},
+		}
+		for _, tgt := range r.targetByIndId[ind.Id] {
+			newInd.Target = append(newInd.Target, TargetIndikator{
+				IdTarget:    tgt.Id,
+				IndikatorId: ind.Id,
+				Target:      tgt.Target,
+				Satuan:      tgt.Satuan,
+				Tahun:       tgt.Tahun,
+			})
+		}
+
+		result[ind.PokinId] = append(result[ind.PokinId], newInd)
+	}
+
+	return result, nil
+}
+
+func (r *memoryRepository) GetPaguByPokinIds(ctx context.Context, idPokins []int) (map[string]Pagu, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	wanted := make(map[int]bool, len(idPokins))
+	for _, id := range idPokins {
+		wanted[id] = true
+	}
+
+	var idRekins []string
+	for _, rk := range r.fx.RencanaKinerja {
+		if wanted[rk.IdPohon] {
+			idRekins = append(idRekins, rk.Id)
+		}
+	}
+
+	return r.GetPaguByRekinIds(ctx, idRekins)
+}
+
+func (r *memoryRepository) GetPaguByRekinIds(ctx context.Context, idRekins []string) (map[string]Pagu, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]Pagu)
+
+	for _, id := range uniqueStrings(idRekins) {
+		var total Pagu
+		found := false
+		for _, ra := range r.renaksiByRekin[id] {
+			for _, rb := range r.rinbelByRenaksi[ra.Id] {
+				total += Pagu(rb.Anggaran)
+				found = true
+			}
+		}
+		if found {
+			result[id] = total
+		}
+	}
+
+	return result, nil
+}
+
+func (r *memoryRepository) GetDetailByKodeProgramUnggulan(ctx context.Context, kode string) ([]Pokin, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return r.detailPokin([]string{kode}, false)
+}
+
+func (r *memoryRepository) GetDetailBatchByKodeProgramUnggulan(ctx context.Context, kodes []string) ([]Pokin, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return r.detailPokin(kodes, true)
+}
+
+// detailPokin menyusun pokin per kode program unggulan,
+// withPelaksana ikut mengisi pelaksana dan rekin seperti query batch
+func (r *memoryRepository) detailPokin(kodes []string, withPelaksana bool) ([]Pokin, error) {
+	wanted := make(map[string]bool, len(kodes))
+	for _, k := range kodes {
+		wanted[k] = true
+	}
+
+	var listPokin []Pokin
+	seen := make(map[int]bool)
+	for _, ket := range r.fx.KeteranganTagging {
+		if !wanted[ket.KodeProgramUnggulan] {
+			continue
+		}
+		prog, ok := r.prungByKode[ket.KodeProgramUnggulan]
+		if !ok {
+			continue
+		}
+		tag, ok := r.taggingById[ket.IdTagging]
+		if !ok {
+			continue
+		}
+		pokin := r.pokinById[tag.IdPokin]
+		if seen[pokin.Id] {
+			continue
+		}
+		seen[pokin.Id] = true
+
+		po := Pokin{
+			KodeProgramUnggulan: prog.KodeProgramUnggulan,
+			NamaProgramUnggulan: prog.NamaTagging,
+			RencanaImplementasi: prog.KeteranganProgramUnggulan,
+			IdTagging:           tag.Id,
+			IdPohon:             pokin.Id,
+			NamaPohon:           pokin.NamaPohon,
+			Tahun:               Tahun(pokin.Tahun),
+			JenisPohon:          JenisPohon(pokin.JenisPohon),
+			KodeOpd:             r.opdByKode[pokin.KodeOpd].KodeOpd,
+			NamaOpd:             r.opdByKode[pokin.KodeOpd].NamaOpd,
+			Indikator:           []IndikatorPohon{},
+		}
+
+		for _, ind := range r.fx.Indikator {
+			if ind.PokinId != pokin.Id {
+				continue
+			}
+			newInd := IndikatorPohon{
+				IdIndikator: ind.Id,
+				Indikator:   ind.Indikator,
+				Target:      []TargetIndikator{},
+			}
+			for _, tgt := range r.targetByIndId[ind.Id] {
+				newInd.Target = append(newInd.Target, TargetIndikator{
+					IdTarget: tgt.Id,
+					Target:   tgt.Target,
+					Satuan:   tgt.Satuan,
+					Tahun:    tgt.Tahun,
+				})
+			}
+			po.Indikator = append(po.Indikator, newInd)
+		}
+
+		if withPelaksana {
+			po.IdProgramUnggulan = prog.Id
+			po.Pelaksanas = r.pelaksanaPokin(pokin.Id)
+		}
+
+		listPokin = append(listPokin, po)
+	}
+
+	return listPokin, nil
+}
+
+// pelaksana dari tb_pelaksana_pokin beserta rekin milik pegawai tsb di pokin yang sama
+func (r *memoryRepository) pelaksanaPokin(pokinId int) []PelaksanaPokin {
+	pelaksanas := []PelaksanaPokin{}
+	for _, pp := range r.fx.PelaksanaPokin {
+		if pp.PohonKinerjaId != pokinId {
+			continue
+		}
+		pegawai, ok := r.pegawaiById[pp.PegawaiId]
+		if !ok {
+			continue
+		}
+
+		pelaksana := PelaksanaPokin{
+			IdPelaksana:     pegawai.Id,
+			NamaPelaksana:   pegawai.Nama,
+			NIPPelaksana:    pegawai.Nip,
+			RencanaKinerjas: []RencanaKinerjaAsn{},
+		}
+		for _, rk := range r.fx.RencanaKinerja {
+			if rk.IdPohon != pokinId || rk.PegawaiId != pegawai.Nip {
+				continue
+			}
+			sub := r.subByKode[rk.KodeSubkegiatan]
+			pelaksana.RencanaKinerjas = append(pelaksana.RencanaKinerjas, RencanaKinerjaAsn{
+				IdRekin:         rk.Id,
+				RencanaKinerja:  rk.NamaRencanaKinerja,
+				KodeSubkegiatan: sub.KodeSubkegiatan,
+				NamaSubkegiatan: sub.NamaSubkegiatan,
+			})
+		}
+
+		pelaksanas = append(pelaksanas, pelaksana)
+	}
+	return pelaksanas
+}
+
+func (r *memoryRepository) GetRencanaAksiByRekinIds(ctx context.Context, idRekins []string) (map[string][]RencanaAksi, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	result := make(map[string][]RencanaAksi)
+	for _, id := range idRekins {
+		if _, done := result[id]; done {
+			continue
+		}
+		renaksis := append([]FixtureRencanaAksi{}, r.renaksiByRekin[id]...)
+		if len(renaksis) == 0 {
+			continue
+		}
+		sort.SliceStable(renaksis, func(i, j int) bool {
+			return renaksis[i].Id < renaksis[j].Id
+		})
+
+		aksis := make([]RencanaAksi, 0, len(renaksis))
+		for _, ra := range renaksis {
+			aksi := RencanaAksi{
+				IdRencanaAksi: ra.Id,
+				BobotBulanan:  newBobotBulanan(),
+			}
+			for _, pl := range r.bobotByRenaksi[ra.Id] {
+				aksi.addBobot(pl.Bulan, pl.Bobot)
+			}
+			aksis = append(aksis, aksi)
+		}
+		result[id] = aksis
+	}
+	return result, nil
+}
+
+func (r *memoryRepository) GetBidangUrusanProgramByIdPokins(ctx context.Context, idPokins []int) (map[int]BidangUrusanProgram, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	result := make(map[int]BidangUrusanProgram)
+	for _, rootId := range uniqueInts(idPokins) {
+		if _, ok := r.pokinById[rootId]; !ok {
+			continue
+		}
+
+		// pokin sendiri, anak dan cucu, sama dengan UNION 3 level di query MySQL
+		tree := []int{rootId}
+		for _, child := range r.childrenById[rootId] {
+			tree = append(tree, child)
+			tree = append(tree, r.childrenById[child]...)
+		}
+
+		var bp BidangUrusanProgram
+		for _, id := range tree {
+			jenis := r.pokinById[id].JenisPohon
+			if jenis != "Operational" && jenis != "Operational Pemda" {
+				continue
+			}
+			for _, rk := range r.fx.RencanaKinerja {
+				if rk.IdPohon != id || rk.KodeSubkegiatan == "" {
+					continue
+				}
+				var bidur BidangUrusan
+				if len(rk.KodeSubkegiatan) >= 4 {
+					bidur = r.bidurByKode[rk.KodeSubkegiatan[:4]]
+				}
+				prg := r.programByKode[kodeProgramFromSubkegiatan(rk.KodeSubkegiatan)]
+				bp.add(bidur, Program{KodeProgram: prg.KodeProgram, NamaProgram: prg.NamaProgram})
+			}
+		}
+		if len(bp.BidangUrusans) > 0 || len(bp.Programs) > 0 {
+			bp.sortByKode()
+			result[rootId] = bp
+		}
+	}
+
+	return result, nil
+}
+
+// kode program = 3 segmen pertama kode subkegiatan, sama dengan SUBSTRING_INDEX(kode, '.', 3)
+func kodeProgramFromSubkegiatan(kodeSub string) string {
+	parts := strings.SplitN(kodeSub, ".", 4)
+	if len(parts) > 3 {
+		parts = parts[:3]
+	}
+	return strings.Join(parts, ".")
+}
+
+func uniqueInts(ids []int) []int {
+	seen := make(map[int]bool, len(ids))
+	var result []int
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			result = append(result, id)
+		}
+	}
+	return result
+}
+
+func uniqueStrings(ids []string) []string {
+	seen := make(map[string]bool, len(ids))
+	var result []string
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			result = append(result, id)
+		}
+	}
+	return result
+}
@@ -0,0 +1,1188 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type mysqlRepository struct {
+	db   *sql.DB
+	tags *TagRegistry
+}
+
+func NewMySQLRepository(db *sql.DB, tags *TagRegistry) TaggingRepository {
+	return &mysqlRepository{db: db, tags: tags}
+}
+
+// batas pokin id per query hierarki, placeholder dipakai 3 kali (3000, jauh di bawah batas 65535 MySQL).
+// Sama dengan batch rekin supaya laporan sampai 1000 pokin tetap satu query per langkah.
+const bidangUrusanProgramBatchSize = 1000
+
+// GetBidangUrusanProgramByIdPokins menelusuri pohon sampai 2 level ke bawah
+// (strategic -> tactical -> operational) untuk semua pokin sekaligus.
+// Bidang urusan dan program diambil dari subkegiatan rekin di pokin operational,
+// pokin operational memakai rekinnya sendiri.
+func (r *mysqlRepository) GetBidangUrusanProgramByIdPokins(ctx context.Context, idPokins []int) (map[int]BidangUrusanProgram, error) {
+	result := make(map[int]BidangUrusanProgram)
+
+	ids := uniqueInts(idPokins)
+	for start := 0; start < len(ids); start += bidangUrusanProgramBatchSize {
+		end := min(start+bidangUrusanProgramBatchSize, len(ids))
+		chunk := ids[start:end]
+
+		placeholders := make([]string, len(chunk))
+		idArgs := make([]any, len(chunk))
+		for i, id := range chunk {
+			placeholders[i] = "?"
+			idArgs[i] = id
+		}
+		in := strings.Join(placeholders, ",")
+
+		query := fmt.Sprintf(`
+		SELECT DISTINCT tree.root_id,
+		       bidur.kode_bidang_urusan, bidur.nama_bidang_urusan,
+		       prg.kode_program, prg.nama_program
+		FROM (
+			SELECT pokin.id AS root_id, pokin.id AS pokin_id, pokin.jenis_pohon
+			FROM tb_pohon_kinerja pokin
+			WHERE pokin.id IN (%[1]s)
+			UNION ALL
+			SELECT pokin.id, child.id, child.jenis_pohon
+			FROM tb_pohon_kinerja pokin
+			JOIN tb_pohon_kinerja child ON child.parent = pokin.id
+			WHERE pokin.id IN (%[1]s)
+			UNION ALL
+			SELECT pokin.id, grandchild.id, grandchild.jenis_pohon
+			FROM tb_pohon_kinerja pokin
+			JOIN tb_pohon_kinerja child ON child.parent = pokin.id
+			JOIN tb_pohon_kinerja grandchild ON grandchild.parent = child.id
+			WHERE pokin.id IN (%[1]s)
+		) tree
+		JOIN tb_rencana_kinerja rekin ON rekin.id_pohon = tree.pokin_id
+		JOIN tb_subkegiatan_terpilih sub_rekin ON sub_rekin.rekin_id = rekin.id
+		LEFT JOIN tb_bidang_urusan bidur ON bidur.kode_bidang_urusan = SUBSTRING(sub_rekin.kode_subkegiatan, 1, 4)
+		LEFT JOIN tb_master_program prg ON prg.kode_program = SUBSTRING_INDEX(sub_rekin.kode_subkegiatan, '.', 3)
+		WHERE tree.jenis_pohon IN ('Operational', 'Operational Pemda')
+		ORDER BY tree.root_id, bidur.kode_bidang_urusan, prg.kode_program
+		`, in)
+
+		args := make([]any, 0, len(idArgs)*3)
+		for range 3 {
+			args = append(args, idArgs...)
+		}
+
+		rows, err := r.db.QueryContext(ctx, query, args...)
+		if err != nil {
+			return nil, fmt.Errorf("query error: %w", err)
+		}
+
+		for rows.Next() {
+			var rootId int
+			var kodeBidur, namaBidur, kodePrg, namaPrg sql.NullString
+			if err := rows.Scan(&rootId, &kodeBidur, &namaBidur, &kodePrg, &namaPrg); err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("scan error: %w", err)
+			}
+			bp := result[rootId]
+			bp.add(
+				BidangUrusan{KodeBidangUrusan: kodeBidur.String, NamaBidangUrusan: namaBidur.String},
+				Program{KodeProgram: kodePrg.String, NamaProgram: namaPrg.String},
+			)
+			result[rootId] = bp
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return nil, fmt.Errorf("rows error: %w", err)
+		}
+	}
+
+	for id, bp := range result {
+		bp.sortByKode()
+		result[id] = bp
+	}
+	return result, nil
+}
+
+// indikatorProgramKey kunci indikator program di tb_indikator_matrix
+type indikatorProgramKey struct {
+	kodeProgram string
+	kodeOpd     string
+	tahun       int
+}
+
+// batas key per query, 3 placeholder per key
+const indikatorProgramBatchSize = 1000
+
+// getIndikatorProgramBatch mengambil indikator program untuk banyak (kode_program, kode_opd, tahun)
+// sekaligus, satu query per 1000 key, bukan satu query per rekin
+func (r *mysqlRepository) getIndikatorProgramBatch(ctx context.Context, keys []indikatorProgramKey) (map[indikatorProgramKey][]IndikatorProgram, error) {
+	result := make(map[indikatorProgramKey][]IndikatorProgram)
+
+	for start := 0; start < len(keys); start += indikatorProgramBatchSize {
+		end := min(start+indikatorProgramBatchSize, len(keys))
+		chunk := keys[start:end]
+
+		placeholders := make([]string, len(chunk))
+		args := make([]any, 0, len(chunk)*3)
+		for i, k := range chunk {
+			placeholders[i] = "(?, ?, ?)"
+			args = append(args, k.kodeProgram, k.kodeOpd, k.tahun)
+		}
+
+		query := fmt.Sprintf(`
+			SELECT im.kode, im.kode_opd, im.tahun, im.indikator
+			FROM tb_indikator_matrix im
+			WHERE (im.kode, im.kode_opd, im.tahun) IN (%s)
+			AND im.jenis  = 'penetapan'
+			ORDER BY im.kode, im.kode_opd, im.tahun, im.kode_indikator
+		`, strings.Join(placeholders, ","))
+
+		rows, err := r.db.QueryContext(ctx, query, args...)
+		if err != nil {
+			return nil, fmt.Errorf("query error: %w", err)
+		}
+
+		for rows.Next() {
+			var key indikatorProgramKey
+			var indPrg IndikatorProgram
+			if err := rows.Scan(&key.kodeProgram, &key.kodeOpd, &key.tahun, &indPrg.Indikator); err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("scan error: %w", err)
+			}
+			result[key] = append(result[key], indPrg)
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return nil, fmt.Errorf("rows error: %w", err)
+		}
+	}
+
+	return result, nil
+}
+
+// batas rekin id per query pelaksanaan renaksi
+const pelaksanaanRenaksiBatchSize = 1000
+
+// getPelaksanaanRenaksiBatch rekin id -> bobot per triwulan dari pelaksanaan rencana aksi
+func (r *mysqlRepository) getPelaksanaanRenaksiBatch(ctx context.Context, idRekins []string) (map[string]WaktuPelaksanaan, error) {
+	result := make(map[string]WaktuPelaksanaan)
+
+	for start := 0; start < len(idRekins); start += pelaksanaanRenaksiBatchSize {
+		end := min(start+pelaksanaanRenaksiBatchSize, len(idRekins))
+		chunk := idRekins[start:end]
+
+		placeholders := make([]string, len(chunk))
+		args := make([]any, len(chunk))
+		for i, id := range chunk {
+			placeholders[i] = "?"
+			args[i] = id
+		}
+
+		query := fmt.Sprintf(`
+			SELECT tb_rencana_aksi.rencana_kinerja_id, renaksi.bulan, renaksi.bobot
+			FROM tb_pelaksanaan_rencana_aksi renaksi
+			JOIN tb_rencana_aksi ON tb_rencana_aksi.id = renaksi.rencana_aksi_id
+			WHERE tb_rencana_aksi.rencana_kinerja_id IN (%s)`, strings.Join(placeholders, ","))
+
+		rows, err := r.db.QueryContext(ctx, query, args...)
+		if err != nil {
+			return nil, fmt.Errorf("query error: %w", err)
+		}
+
+		for rows.Next() {
+			var idRekin string
+			var bulan, bobot int
+			if err := rows.Scan(&idRekin, &bulan, &bobot); err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("scan error: %w", err)
+			}
+			wp := result[idRekin]
+			wp.addBobot(bulan, bobot)
+			result[idRekin] = wp
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return nil, fmt.Errorf("rows error: %w", err)
+		}
+	}
+
+	return result, nil
+}
+
+// GetRencanaAksiByRekinIds rencana aksi tiap rekin dengan bobot per bulan dari tb_pelaksanaan_rencana_aksi,
+// rencana aksi tanpa pelaksanaan tetap dikirim dengan bobot 0
+func (r *mysqlRepository) GetRencanaAksiByRekinIds(ctx context.Context, idRekins []string) (map[string][]RencanaAksi, error) {
+	result := make(map[string][]RencanaAksi)
+
+	for start := 0; start < len(idRekins); start += pelaksanaanRenaksiBatchSize {
+		end := min(start+pelaksanaanRenaksiBatchSize, len(idRekins))
+		chunk := idRekins[start:end]
+
+		placeholders := make([]string, len(chunk))
+		args := make([]any, len(chunk))
+		for i, id := range chunk {
+			placeholders[i] = "?"
+			args[i] = id
+		}
+
+		query := fmt.Sprintf(`
+			SELECT ra.rencana_kinerja_id, ra.id, renaksi.bulan, renaksi.bobot
+			FROM tb_rencana_aksi ra
+			LEFT JOIN tb_pelaksanaan_rencana_aksi renaksi ON renaksi.rencana_aksi_id = ra.id
+			WHERE ra.rencana_kinerja_id IN (%s)
+			ORDER BY ra.rencana_kinerja_id, ra.id, renaksi.bulan`, strings.Join(placeholders, ","))
+
+		rows, err := r.db.QueryContext(ctx, query, args...)
+		if err != nil {
+			return nil, fmt.Errorf("query error: %w", err)
+		}
+
+		for rows.Next() {
+			var idRekin, idRenaksi string
+			var bulan, bobot sql.NullInt64
+			if err := rows.Scan(&idRekin, &idRenaksi, &bulan, &bobot); err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("scan error: %w", err)
+			}
+
+			// hasil urut rekin lalu rencana aksi, cukup cek elemen terakhir
+			aksis := result[idRekin]
+			if len(aksis) == 0 || aksis[len(aksis)-1].IdRencanaAksi != idRenaksi {
+				aksis = append(aksis, RencanaAksi{
+					IdRencanaAksi: idRenaksi,
+					BobotBulanan:  newBobotBulanan(),
+				})
+			}
+			if bulan.Valid {
+				aksis[len(aksis)-1].addBobot(int(bulan.Int64), int(bobot.Int64))
+			}
+			result[idRekin] = aksis
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return nil, fmt.Errorf("rows error: %w", err)
+		}
+	}
+
+	return result, nil
+}
+
+func (r *mysqlRepository) GetPaguByPokinIds(ctx context.Context, idPokins []int) (map[string]Pagu, error) {
+	if len(idPokins) == 0 {
+		return map[string]Pagu{}, nil
+	}
+
+	placeholders := make([]string, len(idPokins))
+	args := make([]any, len(idPokins))
+
+	for i, id := range idPokins {
+		placeholders[i] = "?"
+		args[i] = id
+	}
+
+	query := fmt.Sprintf(`
+		SELECT
+			rekin.id,
+			SUM(rinbel.anggaran) AS total_pagu
+		FROM tb_rencana_kinerja rekin
+		JOIN tb_pohon_kinerja pokin ON rekin.id_pohon = pokin.id
+		JOIN tb_rencana_aksi renaksi ON renaksi.rencana_kinerja_id = rekin.id
+		JOIN tb_rincian_belanja rinbel ON rinbel.renaksi_id = renaksi.id
+		WHERE pokin.id IN (%s)
+		GROUP BY rekin.id
+	`, strings.Join(placeholders, ","))
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make(map[string]Pagu)
+
+	for rows.Next() {
+		var id string
+		var total sql.NullInt64
+
+		if err := rows.Scan(&id, &total); err != nil {
+			return nil, err
+		}
+
+		if total.Valid {
+			result[id] = Pagu(total.Int64)
+		}
+	}
+
+	return result, nil
+}
+
+func (r *mysqlRepository) GetRencanaKinerjaByIdPokins(ctx context.Context, req []IdPokinsJenisPohon, tahun int) (map[int][]PelaksanaPokin, error) {
+	result := make(map[int][]PelaksanaPokin)
+
+	if len(req) == 0 {
+		return result, nil
+	}
+
+	// map pokin → jenis
+	jenisMap := make(map[int]string)
+	var idPokins []int
+
+	for _, rq := range req {
+		idPokins = append(idPokins, rq.idPokin)
+		jenisMap[rq.idPokin] = rq.jenisPohon
+	}
+
+	// pagu batch
+	paguMap, err := r.GetPaguByPokinIds(ctx, idPokins)
+	if err != nil {
+		return nil, err
+	}
+
+	// placeholder query
+	placeholders := make([]string, len(idPokins))
+	args := make([]any, len(idPokins))
+
+	for i, id := range idPokins {
+		placeholders[i] = "?"
+		args[i] = id
+	}
+
+	query := fmt.Sprintf(`
+	SELECT DISTINCT
+	       pokin.id,
+	       rekin.id,
+	       rekin.nama_rencana_kinerja,
+	       pegawai.id,
+	       pegawai.nama,
+	       pegawai.nip,
+	       subkegiatan.kode_subkegiatan,
+	       subkegiatan.nama_subkegiatan,
+	       prog.kode_program,
+               prog.nama_program,
+	       rekin.catatan,
+               rekin.kode_opd
+	FROM tb_rencana_kinerja rekin
+	JOIN tb_pegawai pegawai ON pegawai.nip = rekin.pegawai_id
+	JOIN tb_pohon_kinerja pokin ON rekin.id_pohon = pokin.id
+	LEFT JOIN tb_subkegiatan_terpilih sub_rekin ON sub_rekin.rekin_id = rekin.id
+	LEFT JOIN tb_subkegiatan subkegiatan ON subkegiatan.kode_subkegiatan = sub_rekin.kode_subkegiatan
+        LEFT JOIN tb_master_program prog ON prog.kode_program = SUBSTRING_INDEX(sub_rekin.kode_subkegiatan, '.', 3)
+	WHERE rekin.kode_opd = pokin.kode_opd
+	AND pokin.id IN (%s)
+	`, strings.Join(placeholders, ","))
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	// pokin → nip → pelaksana
+	pelaksanaMap := make(map[int]map[string]*PelaksanaPokin)
+	seen := make(map[int]map[string]map[string]Pagu)
+
+	// rekin id → key indikator program, diambil sekaligus setelah scan
+	rekinIndikatorKeys := make(map[string]indikatorProgramKey)
+	var idRekins []string
+	var indikatorKeys []indikatorProgramKey
+	seenIndikatorKey := make(map[indikatorProgramKey]bool)
+
+	for rows.Next() {
+
+		var pokinId int
+		var rekin RencanaKinerjaAsn
+		var kodeSub, namaSub,
+			kodePrg, namaPrg sql.NullString
+		var kodeOpd, idPegawai string
+
+		if err := rows.Scan(
+			&pokinId,
+			&rekin.IdRekin,
+			&rekin.RencanaKinerja,
+			&idPegawai,
+			&rekin.NamaPelaksana,
+			&rekin.NIPPelaksana,
+			&kodeSub,
+			&namaSub,
+			&kodePrg,
+			&namaPrg,
+			&rekin.Catatan,
+			&kodeOpd,
+		); err != nil {
+			return nil, err
+		}
+
+		if kodeSub.Valid {
+			rekin.KodeSubkegiatan = kodeSub.String
+		}
+		if namaSub.Valid {
+			rekin.NamaSubkegiatan = namaSub.String
+		}
+
+		if kodePrg.Valid {
+			rekin.KodeProgram = kodePrg.String
+		}
+
+		if namaPrg.Valid {
+			rekin.NamaProgram = namaPrg.String
+		}
+
+		if p, ok := paguMap[rekin.IdRekin]; ok {
+			rekin.Pagu = p
+		}
+
+		// init maps
+		if _, ok := pelaksanaMap[pokinId]; !ok {
+			pelaksanaMap[pokinId] = make(map[string]*PelaksanaPokin)
+			seen[pokinId] = make(map[string]map[string]Pagu)
+		}
+
+		key := rekin.NIPPelaksana
+
+		if _, ok := pelaksanaMap[pokinId][key]; !ok {
+			pelaksanaMap[pokinId][key] = &PelaksanaPokin{
+				IdPelaksana:   idPegawai,
+				NamaPelaksana: rekin.NamaPelaksana,
+				NIPPelaksana:  rekin.NIPPelaksana,
+			}
+			seen[pokinId][key] = make(map[string]Pagu)
+		}
+
+		if existing, ok := seen[pokinId][key][rekin.IdRekin]; ok {
+
+			seen[pokinId][key][rekin.IdRekin] = existing + rekin.Pagu
+
+			for i := range pelaksanaMap[pokinId][key].RencanaKinerjas {
+				if pelaksanaMap[pokinId][key].RencanaKinerjas[i].IdRekin == rekin.IdRekin {
+					pelaksanaMap[pokinId][key].RencanaKinerjas[i].Pagu =
+						seen[pokinId][key][rekin.IdRekin]
+				}
+			}
+
+		} else {
+
+			idRekins = append(idRekins, rekin.IdRekin)
+			seen[pokinId][key][rekin.IdRekin] = rekin.Pagu
+
+			// key indikator dari baris yang disimpan, bukan baris subkegiatan berikutnya
+			if rekin.KodeProgram != "" {
+				indKey := indikatorProgramKey{kodeProgram: rekin.KodeProgram, kodeOpd: kodeOpd, tahun: tahun}
+				rekinIndikatorKeys[rekin.IdRekin] = indKey
+				if !seenIndikatorKey[indKey] {
+					seenIndikatorKey[indKey] = true
+					indikatorKeys = append(indikatorKeys, indKey)
+				}
+			}
+			pelaksanaMap[pokinId][key].RencanaKinerjas =
+				append(pelaksanaMap[pokinId][key].RencanaKinerjas, rekin)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// gagal di pelengkap rekin: hasil tetap dikembalikan bersama PartialError
+	var partials []error
+
+	indPrograms, indErr := r.getIndikatorProgramBatch(ctx, indikatorKeys)
+	if indErr != nil && ctx.Err() != nil {
+		return nil, indErr
+	} else if indErr != nil {
+		partials = append(partials, &PartialError{Step: "indikator_program", Message: "indikator program rencana kinerja gagal dimuat", Err: indErr})
+	}
+
+	tahapan, tahapanErr := r.getPelaksanaanRenaksiBatch(ctx, idRekins)
+	if tahapanErr != nil && ctx.Err() != nil {
+		return nil, tahapanErr
+	} else if tahapanErr != nil {
+		partials = append(partials, &PartialError{Step: "tahapan_pelaksanaan", Message: "tahapan pelaksanaan (TW) rencana kinerja gagal dimuat", Err: tahapanErr})
+	}
+
+	// convert map
+	for pokinId, pelaksanas := range pelaksanaMap {
+
+		for _, p := range pelaksanas {
+			for i := range p.RencanaKinerjas {
+				p.RencanaKinerjas[i].TahapanPelaksanaan = tahapan[p.RencanaKinerjas[i].IdRekin]
+
+				key, ok := rekinIndikatorKeys[p.RencanaKinerjas[i].IdRekin]
+				if !ok || indErr != nil {
+					continue
+				}
+				if ind, ok := indPrograms[key]; ok {
+					p.RencanaKinerjas[i].IndikatorPrograms = ind
+				} else {
+					p.RencanaKinerjas[i].IndikatorPrograms = make([]IndikatorProgram, 0)
+				}
+			}
+			result[pokinId] = append(result[pokinId], *p)
+		}
+		sortPelaksanas(result[pokinId])
+	}
+
+	return result, errors.Join(partials...)
+}
+
+func (r *mysqlRepository) GetIndikatorPokinByIdPokins(ctx context.Context, idPokins []int) (map[int][]IndikatorPohon, error) {
+	result := make(map[int][]IndikatorPohon)
+
+	if len(idPokins) == 0 {
+		return result, nil
+	}
+
+	placeholders := make([]string, len(idPokins))
+	args := make([]any, len(idPokins))
+
+	for i, id := range idPokins {
+		placeholders[i] = "?"
+		args[i] = id
+	}
+
+	query := fmt.Sprintf(`
+	SELECT
+		ind.id,
+		ind.pokin_id,
+		ind.indikator,
+		tgt.id,
+		tgt.target,
+		tgt.satuan,
+		tgt.tahun
+	FROM tb_indikator ind
+	LEFT JOIN tb_target tgt ON tgt.indikator_id = ind.id
+	WHERE ind.pokin_id IN (%s)
+	ORDER BY ind.pokin_id, ind.id
+	`, strings.Join(placeholders, ","))
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	// untuk deduplicate indikator
+	indikatorMap := make(map[string]*IndikatorPohon)
+
+	for rows.Next() {
+
+		var (
+			indId     string
+			pokinId   int
+			indikator string
+			tgtId     sql.NullString
+			tgtVal    sql.NullString
+			satuan    sql.NullString
+			tahun     sql.NullInt64
+		)
+
+		err := rows.Scan(
+			&indId,
+			&pokinId,
+			&indikator,
+			&tgtId,
+			&tgtVal,
+			&satuan,
+			&tahun,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		// unique key indikator
+		key := fmt.Sprintf("%d-%s", pokinId, indId)
+
+		ind, exists := indikatorMap[key]
+		if !exists {
+
+			newInd := IndikatorPohon{
+				IdIndikator: indId,
+				IdPokin:     strconv.Itoa(pokinId),
+				Indikator:   indikator,
+				Target:      []TargetIndikator{},
+			}
+
+			indikatorMap[key] = &newInd
+			result[pokinId] = append(result[pokinId], newInd)
+
+			ind = &result[pokinId][len(result[pokinId])-1]
+		}
+
+		// tambah target jika ada
+		if tgtId.Valid {
+
+			target := TargetIndikator{
+				IdTarget:    tgtId.String,
+				IndikatorId: indId,
+				Target:      tgtVal.String,
+				Satuan:      satuan.String,
+			}
+
+			if tahun.Valid {
+				target.Tahun = int(tahun.Int64)
+			}
+
+			ind.Target = append(ind.Target, target)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// kolom ORDER BY untuk tiap kunci ?sort=, harus searah dengan pokinSortKeys
+func pokinSortColumns(master TagMaster) map[string]string {
+	return map[string]string{
+		"kode_opd":              "pokin.kode_opd",
+		"nama_opd":              "opd.nama_opd",
+		"jenis_pohon":           jenisPohonLevelSQL,
+		"id_pohon":              "pokin.id",
+		"nama_pohon":            "pokin.nama_pohon",
+		"kode_program_unggulan": master.selectColumn(master.KodeColumn),
+	}
+}
+
+// sama dengan jenisPohonLevel
+const jenisPohonLevelSQL = `CASE
+            WHEN pokin.jenis_pohon IN ('Strategic', 'Strategic Pemda') THEN 1
+            WHEN pokin.jenis_pohon IN ('Tactical', 'Tactical Pemda') THEN 2
+            WHEN pokin.jenis_pohon IN ('Operational', 'Operational Pemda') THEN 3
+            ELSE 4 END`
+
+// pokinOrderBy kolom sort lalu urutan bawaan (sama dengan defaultPokinCompare)
+func pokinOrderBy(master TagMaster, sort PokinSort) string {
+	columns := pokinSortColumns(master)
+
+	var order []string
+	for _, f := range sort {
+		col := columns[f.Name]
+		if f.Desc {
+			col += " DESC"
+		}
+		order = append(order, col)
+	}
+	order = append(order,
+		"pokin.kode_opd",
+		jenisPohonLevelSQL,
+		"pokin.id",
+		columns["kode_program_unggulan"],
+		"tag.id",
+	)
+	return strings.Join(order, ", ")
+}
+
+// LIKE tanpa wildcard dari input user
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+func (r *mysqlRepository) GetPokinByTagging(ctx context.Context, namaTagging string, tahun int, q PokinQuery) ([]Pokin, int, error) {
+	master := r.tags.Lookup(namaTagging).Master()
+
+	from := fmt.Sprintf(`
+        FROM tb_pohon_kinerja pokin
+        JOIN tb_operasional_daerah opd ON opd.kode_opd = pokin.kode_opd
+        JOIN tb_tagging_pokin tag ON tag.id_pokin = pokin.id
+            AND pokin.tahun = ?
+            AND pokin.kode_opd != ""
+            AND pokin.status IN ("pokin dari pemda", "")
+        JOIN tb_keterangan_tagging_program_unggulan prung ON prung.id_tagging = tag.id
+        JOIN %s master ON prung.kode_program_unggulan = master.%s
+        WHERE tag.nama_tagging = ?`,
+		master.Table,
+		master.JoinColumn,
+	)
+	args := []any{tahun, namaTagging}
+
+	// filter opsional
+	if q.KodeOpd != "" {
+		from += "\n        AND pokin.kode_opd = ?"
+		args = append(args, q.KodeOpd)
+	}
+	if q.JenisPohon != "" {
+		from += "\n        AND pokin.jenis_pohon = ?"
+		args = append(args, q.JenisPohon)
+	}
+	if q.KodeProgramUnggulan != "" {
+		from += fmt.Sprintf("\n        AND %s = ?", master.selectColumn(master.KodeColumn))
+		args = append(args, q.KodeProgramUnggulan)
+	}
+	if q.Status != "" {
+		from += "\n        AND pokin.status = ?"
+		args = append(args, q.Status)
+	}
+	if q.NamaPohon != "" {
+		from += "\n        AND pokin.nama_pohon LIKE ?"
+		args = append(args, "%"+likeEscaper.Replace(q.NamaPohon)+"%")
+	}
+
+	var total int
+	if err := r.db.QueryRowContext(ctx, "SELECT COUNT(*)"+from, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("count error: %w", err)
+	}
+
+	query := fmt.Sprintf(`
+        SELECT
+            pokin.id,
+            pokin.nama_pohon,
+            pokin.tahun,
+            pokin.jenis_pohon,
+            pokin.kode_opd,
+            opd.nama_opd,
+            tag.keterangan_tagging,
+            pokin.status,
+            %s,
+            %s,
+            %s,
+            %s,
+            pokin.keterangan
+        %s
+        ORDER BY %s`,
+		master.selectIdColumn(),
+		master.selectColumn(master.KodeColumn),
+		master.selectColumn(master.NamaColumn),
+		master.selectColumn(master.DeskripsiColumn),
+		from,
+		pokinOrderBy(master, q.Sort),
+	)
+	if q.Size > 0 {
+		query += "\n        LIMIT ? OFFSET ?"
+		args = append(args, q.Size, q.offset())
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("query error: %w", err)
+	}
+	defer rows.Close()
+
+	var listPokin []Pokin
+	for rows.Next() {
+		var (
+			idPohon             int
+			namaPohon           sql.NullString
+			tahun               sql.NullInt64
+			jenisPohon          sql.NullString
+			kodeOpd             sql.NullString
+			namaOpd             sql.NullString
+			keteranganTagging   sql.NullString
+			status              sql.NullString
+			puId                sql.NullInt64
+			kodeProgramUnggulan sql.NullString
+			namaProgramUnggulan sql.NullString
+			rencanaImplementasi sql.NullString
+			keterangan          sql.NullString
+		)
+
+		if err := rows.Scan(&idPohon, &namaPohon, &tahun, &jenisPohon, &kodeOpd, &namaOpd, &keteranganTagging, &status, &puId, &kodeProgramUnggulan, &namaProgramUnggulan, &rencanaImplementasi, &keterangan); err != nil {
+			return nil, 0, fmt.Errorf("scan error: %w", err)
+		}
+
+		var idProgramUnggulan int
+		if puId.Valid {
+			idProgramUnggulan = int(puId.Int64)
+		}
+
+		listPokin = append(listPokin, Pokin{
+			IdProgramUnggulan:   idProgramUnggulan,
+			KodeProgramUnggulan: toStr(kodeProgramUnggulan),
+			NamaProgramUnggulan: toStr(namaProgramUnggulan),
+			IdPohon:             idPohon,
+			NamaPohon:           toStr(namaPohon),
+			Tahun:               Tahun(tahunToInt(tahun)),
+			JenisPohon:          JenisPohon(toStr(jenisPohon)),
+			KodeOpd:             toStr(kodeOpd),
+			NamaOpd:             toStr(namaOpd),
+			RencanaImplementasi: toStr(rencanaImplementasi),
+			KeteranganTagging:   toStr(keteranganTagging),
+			Status:              toStr(status),
+			Keterangan:          toStr(keterangan),
+		})
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("rows error: %w", err)
+	}
+
+	return listPokin, total, nil
+}
+
+func (r *mysqlRepository) GetDetailByKodeProgramUnggulan(ctx context.Context, kode string) ([]Pokin, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT
+                            ket.kode_program_unggulan,
+                            pu.nama_tagging,
+                            pu.keterangan_program_unggulan,
+                            ket.id_tagging,
+                            pokin.id,
+							pokin.nama_pohon,
+							pokin.tahun,
+							pokin.jenis_pohon,
+							opd.kode_opd,
+                            opd.nama_opd,
+                            ind.id,
+							ind.indikator AS indikator_pokin,
+                            tgt.id,
+                            tgt.target AS target_indikator_pokin,
+                            tgt.satuan AS satuan_target_indikator_pokin,
+                            tgt.tahun AS tahun_target
+                           FROM tb_keterangan_tagging_program_unggulan ket
+                           JOIN tb_program_unggulan pu ON pu.kode_program_unggulan = ket.kode_program_unggulan
+                           JOIN tb_tagging_pokin tag ON tag.id = ket.id_tagging
+                           LEFT JOIN tb_pohon_kinerja pokin ON pokin.id = tag.id_pokin
+						   LEFT JOIN tb_operasional_daerah opd ON opd.kode_opd = pokin.kode_opd
+						   LEFT JOIN tb_indikator ind ON ind.pokin_id = pokin.id
+                           LEFT JOIN tb_target tgt ON tgt.indikator_id = ind.id
+                           WHERE ket.kode_program_unggulan = ?`, kode)
+	if err != nil {
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	defer rows.Close()
+
+	// map[id_pohon]Pokin
+	pokinMap := make(map[int]*Pokin)
+	for rows.Next() {
+		var (
+			pok       Pokin
+			indId     sql.NullString
+			indikator sql.NullString
+			tgtId     sql.NullString
+			tgtVal    sql.NullString
+			satuan    sql.NullString
+			tahun     sql.NullInt64
+		)
+
+		err := rows.Scan(
+			&pok.KodeProgramUnggulan,
+			&pok.NamaProgramUnggulan,
+			&pok.RencanaImplementasi,
+			&pok.IdTagging,
+			&pok.IdPohon,
+			&pok.NamaPohon,
+			&pok.Tahun,
+			&pok.JenisPohon,
+			&pok.KodeOpd,
+			&pok.NamaOpd,
+			&indId,
+			&indikator,
+			&tgtId,
+			&tgtVal,
+			&satuan,
+			&tahun,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("scan error: %w", err)
+		}
+
+		// Ambil pokin atau buat baru
+		existing, ok := pokinMap[pok.IdPohon]
+		if !ok {
+			existing = &Pokin{
+				KodeProgramUnggulan: pok.KodeProgramUnggulan,
+				NamaProgramUnggulan: pok.NamaProgramUnggulan,
+				RencanaImplementasi: pok.RencanaImplementasi,
+				IdTagging:           pok.IdTagging,
+				IdPohon:             pok.IdPohon,
+				NamaPohon:           pok.NamaPohon,
+				Tahun:               pok.Tahun,
+				JenisPohon:          pok.JenisPohon,
+				KodeOpd:             pok.KodeOpd,
+				NamaOpd:             pok.NamaOpd,
+				Indikator:           []IndikatorPohon{},
+			}
+			pokinMap[pok.IdPohon] = existing
+		}
+
+		// Tambahkan indikator (jika ada)
+		if indId.Valid {
+			ind := findIndikator(existing, indId.String)
+			if ind == nil {
+				existing.Indikator = append(existing.Indikator, IndikatorPohon{
+					IdIndikator: indId.String,
+					Indikator:   indikator.String,
+					Target:      []TargetIndikator{},
+				})
+				ind = &existing.Indikator[len(existing.Indikator)-1]
+			}
+			if tgtId.Valid {
+				ind.Target = append(ind.Target, TargetIndikator{
+					IdTarget: tgtId.String,
+					Target:   tgtVal.String,
+					Satuan:   satuan.String,
+					Tahun:    int(tahun.Int64),
+				})
+			}
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	// ubah ke slice
+	var listPokin []Pokin
+	for _, p := range pokinMap {
+		listPokin = append(listPokin, *p)
+	}
+	sortPokins(listPokin)
+
+	return listPokin, nil
+}
+
+func (r *mysqlRepository) GetDetailBatchByKodeProgramUnggulan(ctx context.Context, kodes []string) ([]Pokin, error) {
+	if len(kodes) == 0 {
+		return nil, nil
+	}
+
+	// Siapkan query dynamic (WHERE IN (...))
+	placeholders := make([]string, len(kodes))
+	args := make([]any, len(kodes))
+	for i, kode := range kodes {
+		placeholders[i] = "?"
+		args[i] = kode
+	}
+
+	query := fmt.Sprintf(`
+        SELECT
+            pu.id as id_program_unggulan,
+            ket.kode_program_unggulan,
+            pu.nama_tagging,
+            pu.keterangan_program_unggulan,
+            ket.id_tagging,
+            pokin.id,
+            pokin.nama_pohon,
+            pokin.tahun,
+            pokin.jenis_pohon,
+            opd.kode_opd,
+            opd.nama_opd,
+            ind.id,
+            ind.indikator AS indikator_pokin,
+            tgt.id,
+            tgt.target AS target_indikator_pokin,
+            tgt.satuan AS satuan_target_indikator_pokin,
+            tgt.tahun AS tahun_target,
+            tp.pegawai_id,
+            peg.nama,
+            peg.nip,
+            rekin.id as rekin_id,
+            rekin.nama_rencana_kinerja,
+            sub.kode_subkegiatan,
+            sub.nama_subkegiatan
+        FROM tb_keterangan_tagging_program_unggulan ket
+        JOIN tb_program_unggulan pu ON pu.kode_program_unggulan = ket.kode_program_unggulan
+        JOIN tb_tagging_pokin tag ON tag.id = ket.id_tagging
+        LEFT JOIN tb_pohon_kinerja pokin ON pokin.id = tag.id_pokin
+        LEFT JOIN tb_operasional_daerah opd ON opd.kode_opd = pokin.kode_opd
+        LEFT JOIN tb_indikator ind ON ind.pokin_id = pokin.id
+        LEFT JOIN tb_target tgt ON tgt.indikator_id = ind.id
+        LEFT JOIN tb_pelaksana_pokin tp ON tp.pohon_kinerja_id = pokin.id
+        LEFT JOIN tb_pegawai peg ON tp.pegawai_id = peg.id
+        LEFT JOIN tb_rencana_kinerja rekin ON pokin.id = rekin.id_pohon AND peg.nip = rekin.pegawai_id
+        LEFT JOIN tb_rencana_aksi renaksi ON rekin.id = renaksi.rencana_kinerja_id
+        LEFT JOIN tb_subkegiatan_terpilih tst ON tst.rekin_id = rekin.id
+        LEFT JOIN tb_subkegiatan sub ON tst.subkegiatan_id = sub.id
+        WHERE ket.kode_program_unggulan IN (%s)
+    `, strings.Join(placeholders, ","))
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	defer rows.Close()
+
+	// map[id_pohon]Pokin
+	pokinMap := make(map[int]*Pokin)
+	for rows.Next() {
+		var (
+			pok         Pokin
+			puId        sql.NullInt64
+			indId       sql.NullString
+			indikator   sql.NullString
+			tgtId       sql.NullString
+			tgtVal      sql.NullString
+			satuan      sql.NullString
+			tahun       sql.NullInt64
+			pegawaiId   sql.NullString
+			namaPegawai sql.NullString
+			nip         sql.NullString
+			rekinId     sql.NullString
+			rekin       sql.NullString
+			kodeSub     sql.NullString
+			namaSub     sql.NullString
+		)
+
+		err := rows.Scan(
+			&puId,
+			&pok.KodeProgramUnggulan,
+			&pok.NamaProgramUnggulan,
+			&pok.RencanaImplementasi,
+			&pok.IdTagging,
+			&pok.IdPohon,
+			&pok.NamaPohon,
+			&pok.Tahun,
+			&pok.JenisPohon,
+			&pok.KodeOpd,
+			&pok.NamaOpd,
+			&indId,
+			&indikator,
+			&tgtId,
+			&tgtVal,
+			&satuan,
+			&tahun,
+			&pegawaiId,
+			&namaPegawai,
+			&nip,
+			&rekinId,
+			&rekin,
+			&kodeSub,
+			&namaSub,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("scan error: %w", err)
+		}
+		var idProgramUnggulan int
+		if puId.Valid {
+			idProgramUnggulan = int(puId.Int64)
+		}
+
+		// Ambil pokin atau buat baru
+		existing, ok := pokinMap[pok.IdPohon]
+		if !ok {
+			existing = &Pokin{
+				IdProgramUnggulan:   idProgramUnggulan,
+				KodeProgramUnggulan: pok.KodeProgramUnggulan,
+				NamaProgramUnggulan: pok.NamaProgramUnggulan,
+				RencanaImplementasi: pok.RencanaImplementasi,
+				IdTagging:           pok.IdTagging,
+				IdPohon:             pok.IdPohon,
+				NamaPohon:           pok.NamaPohon,
+				Tahun:               pok.Tahun,
+				JenisPohon:          pok.JenisPohon,
+				KodeOpd:             pok.KodeOpd,
+				NamaOpd:             pok.NamaOpd,
+				Indikator:           []IndikatorPohon{},
+				Pelaksanas:          []PelaksanaPokin{},
+			}
+			pokinMap[pok.IdPohon] = existing
+		}
+
+		if pegawaiId.Valid {
+			pelaksana := findPegawaiId(existing, pegawaiId.String)
+
+			if pelaksana == nil {
+				existing.Pelaksanas = append(existing.Pelaksanas, PelaksanaPokin{
+					IdPelaksana:     pegawaiId.String,
+					NamaPelaksana:   namaPegawai.String,
+					NIPPelaksana:    nip.String,
+					RencanaKinerjas: []RencanaKinerjaAsn{},
+				})
+				pelaksana = &existing.Pelaksanas[len(existing.Pelaksanas)-1]
+			}
+
+			if rekinId.Valid && findRekinId(pelaksana, rekinId.String) == nil {
+				pelaksana.RencanaKinerjas = append(pelaksana.RencanaKinerjas, RencanaKinerjaAsn{
+					IdRekin:         rekinId.String,
+					RencanaKinerja:  rekin.String,
+					Pagu:            Pagu(0),
+					KodeSubkegiatan: kodeSub.String,
+					NamaSubkegiatan: namaSub.String,
+				})
+			}
+		}
+
+		// Tambahkan indikator (jika ada)
+		if indId.Valid {
+			ind := findIndikator(existing, indId.String)
+
+			if ind == nil {
+				existing.Indikator = append(existing.Indikator, IndikatorPohon{
+					IdIndikator: indId.String,
+					Indikator:   indikator.String,
+					Target:      []TargetIndikator{},
+				})
+				ind = &existing.Indikator[len(existing.Indikator)-1]
+			}
+			if tgtVal.Valid {
+				addTargetIfNotExists(
+					ind,
+					tgtId.String,
+					tgtVal.String,
+					satuan.String,
+					int(tahun.Int64),
+				)
+			}
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	// ubah ke slice
+	var listPokin []Pokin
+	for _, p := range pokinMap {
+		listPokin = append(listPokin, *p)
+	}
+	sortPokins(listPokin)
+
+	return listPokin, nil
+}
+
+func (r *mysqlRepository) GetPaguByRekinIds(ctx context.Context, idRekins []string) (map[string]Pagu, error) {
+	paguMap := make(map[string]Pagu)
+	if len(idRekins) == 0 {
+		return paguMap, nil
+	}
+
+	placeholders := make([]string, len(idRekins))
+	args := make([]any, len(idRekins))
+	for i, id := range idRekins {
+		placeholders[i] = "?"
+		args[i] = id
+	}
+
+	query := fmt.Sprintf(`
+        SELECT
+            rekin.id,
+            SUM(bel.anggaran) AS total_pagu
+        FROM tb_rencana_kinerja rekin
+        JOIN tb_rencana_aksi renaksi
+            ON rekin.id = renaksi.rencana_kinerja_id
+        JOIN tb_rincian_belanja bel
+            ON renaksi.id = bel.renaksi_id
+        WHERE rekin.id IN (%s)
+        GROUP BY rekin.id
+    `, strings.Join(placeholders, ","))
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id string
+		var total sql.NullInt64
+
+		if err := rows.Scan(&id, &total); err != nil {
+			return nil, fmt.Errorf("scan error: %w", err)
+		}
+
+		if total.Valid {
+			paguMap[id] = Pagu(total.Int64)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return paguMap, nil
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"time"
+)
+
+// kode error yang bisa dibaca frontend, message boleh berubah tapi kode tetap
+const (
+	errCodeInvalidParam       = "INVALID_PARAM"
+	errCodeInvalidBody        = "INVALID_BODY"
+	errCodeBodyTooLarge       = "BODY_TOO_LARGE"
+	errCodeUnauthorized       = "UNAUTHORIZED"
+	errCodeNotFound           = "NOT_FOUND"
+	errCodeMethodNotAllowed   = "METHOD_NOT_ALLOWED"
+	errCodePreconditionFailed = "PRECONDITION_FAILED"
+	errCodeTimeout            = "TIMEOUT"
+	errCodeNotReady           = "NOT_READY"
+	errCodeInternal           = "INTERNAL_ERROR"
+)
+
+// APIError detail error di envelope Response, detail internal (SQL, stack) hanya masuk log
+type APIError struct {
+	Code   string       `json:"code"`
+	Fields []FieldError `json:"fields,omitempty"`
+}
+
+// FieldError error validasi untuk satu parameter atau field body
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Warning satu langkah pelengkap data laporan yang gagal pada mode partial
+type Warning struct {
+	Step    string `json:"step"`
+	Message string `json:"message"`
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.Error("encode response error", "err", err)
+	}
+}
+
+// writeError membalas error dengan envelope Response yang sama dengan response sukses
+func writeError(w http.ResponseWriter, status int, code, message string, fields ...FieldError) {
+	writeJSON(w, status, Response{
+		Status:  status,
+		Message: message,
+		Error:   &APIError{Code: code, Fields: fields},
+	})
+}
+
+// writeQueryError membalas error dari repository.
+// Deadline endpoint habis -> 504, client sudah putus -> tidak ada balasan.
+func writeQueryError(w http.ResponseWriter, r *http.Request, err error) {
+	ctxErr := r.Context().Err()
+	logger := loggerFrom(r.Context())
+	switch {
+	case errors.Is(err, context.DeadlineExceeded) || errors.Is(ctxErr, context.DeadlineExceeded):
+		logger.Error("query timeout", "err", err)
+		writeError(w, http.StatusGatewayTimeout, errCodeTimeout, "query melebihi batas waktu, coba lagi beberapa saat")
+	case errors.Is(err, context.Canceled) || errors.Is(ctxErr, context.Canceled):
+		logger.Warn("request dibatalkan client", "err", err)
+	default:
+		logger.Error("query error", "err", err)
+		writeError(w, http.StatusInternalServerError, errCodeInternal, "terjadi kesalahan pada server, coba lagi beberapa saat")
+	}
+}
+
+// withTimeout memberi deadline ke context request,
+// semua query handler ikut batal saat deadline habis
+func withTimeout(d time.Duration, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if d <= 0 {
+			next(w, r)
+			return
+		}
+		ctx, cancel := context.WithTimeout(r.Context(), d)
+		defer cancel()
+		next(w, r.WithContext(ctx))
+	}
+}
+
+// recoverMiddleware mengubah panic di handler menjadi 500 JSON, stack hanya ditulis ke log
+func recoverMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			rec := recover()
+			if rec == nil {
+				return
+			}
+			if rec == http.ErrAbortHandler {
+				panic(rec)
+			}
+			loggerFrom(r.Context()).Error("panic", "panic", rec, "stack", string(debug.Stack()))
+			writeError(w, http.StatusInternalServerError, errCodeInternal, "terjadi kesalahan pada server, coba lagi beberapa saat")
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
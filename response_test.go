@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// decodeError membaca envelope error dan memastikan bentuknya sama untuk semua endpoint
+func decodeError(t *testing.T, rec *httptest.ResponseRecorder, status int, code string) *APIError {
+	t.Helper()
+	if rec.Code != status {
+		t.Fatalf("status = %d, want %d\n%s", rec.Code, status, rec.Body)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", ct)
+	}
+	var resp struct {
+		Status  int             `json:"status"`
+		Message string          `json:"message"`
+		Data    json.RawMessage `json:"data"`
+		Error   *APIError       `json:"error"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("body bukan JSON: %v\n%s", err, rec.Body)
+	}
+	if resp.Status != status {
+		t.Errorf("status di body = %d, want %d", resp.Status, status)
+	}
+	if resp.Message == "" {
+		t.Error("message kosong")
+	}
+	if string(resp.Data) != "null" {
+		t.Errorf("data = %s, want null", resp.Data)
+	}
+	if resp.Error == nil || resp.Error.Code != code {
+		t.Fatalf("error = %+v, want code %s", resp.Error, code)
+	}
+	return resp.Error
+}
+
+func TestWriteQueryError(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		ctxErr func() (context.Context, context.CancelFunc)
+		status int
+		code   string
+	}{
+		{name: "error query", err: errors.New("Error 1054: Unknown column"), status: http.StatusInternalServerError, code: errCodeInternal},
+		{name: "deadline query", err: context.DeadlineExceeded, status: http.StatusGatewayTimeout, code: errCodeTimeout},
+		{name: "deadline request", err: errors.New("driver: bad connection"), status: http.StatusGatewayTimeout, code: errCodeTimeout,
+			ctxErr: func() (context.Context, context.CancelFunc) { return context.WithTimeout(context.Background(), 0) }},
+		{name: "client putus", err: context.Canceled, status: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.ctxErr != nil {
+				ctx, cancel := tt.ctxErr()
+				defer cancel()
+				r = r.WithContext(ctx)
+			}
+			rec := httptest.NewRecorder()
+			writeQueryError(rec, r, tt.err)
+
+			if tt.code == "" {
+				if rec.Body.Len() > 0 {
+					t.Errorf("client putus tidak perlu dibalas, body = %s", rec.Body)
+				}
+				return
+			}
+			decodeError(t, rec, tt.status, tt.code)
+			if strings.Contains(rec.Body.String(), tt.err.Error()) {
+				t.Errorf("detail error internal bocor ke response: %s", rec.Body)
+			}
+		})
+	}
+}
+
+func TestRecoverMiddleware(t *testing.T) {
+	h := recoverMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("nil map")
+	}))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	decodeError(t, rec, http.StatusInternalServerError, errCodeInternal)
+	if strings.Contains(rec.Body.String(), "nil map") {
+		t.Errorf("isi panic bocor ke response: %s", rec.Body)
+	}
+}
+
+func TestHandlerErrorEnvelope(t *testing.T) {
+	fx, err := loadDemoFixture("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tags, err := loadTagRegistry("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := newServer(NewMemoryRepository(fx, tags), newMetrics(), ReportConfig{})
+
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		method  string
+		target  string
+		body    string
+		status  int
+		code    string
+		fields  []string
+	}{
+		{name: "laporan tanpa parameter", handler: srv.laporanHandler, method: http.MethodGet, target: "/laporan/tagging_pokin",
+			status: http.StatusBadRequest, code: errCodeInvalidParam, fields: []string{"nama_tagging", "tahun"}},
+		{name: "laporan tahun bukan angka", handler: srv.laporanHandler, method: http.MethodGet,
+			target: "/laporan/tagging_pokin?nama_tagging=RB&tahun=abc",
+			status: http.StatusBadRequest, code: errCodeInvalidParam, fields: []string{"tahun"}},
+		{name: "laporan format tidak dikenal", handler: srv.laporanHandler, method: http.MethodGet,
+			target: "/laporan/tagging_pokin?nama_tagging=RB&tahun=2025&format=doc",
+			status: http.StatusBadRequest, code: errCodeInvalidParam, fields: []string{"format"}},
+		{name: "xlsx dengan halaman", handler: srv.laporanHandler, method: http.MethodGet,
+			target: "/laporan/tagging_pokin?nama_tagging=RB&tahun=2025&format=xlsx&page=2&size=10",
+			status: http.StatusBadRequest, code: errCodeInvalidParam, fields: []string{"page", "size"}},
+		{name: "pdf dengan ukuran halaman", handler: srv.laporanHandler, method: http.MethodGet,
+			target: "/laporan/tagging_pokin?nama_tagging=RB&tahun=2025&format=pdf&size=10",
+			status: http.StatusBadRequest, code: errCodeInvalidParam, fields: []string{"size"}},
+		{name: "summary tanpa parameter", handler: srv.summaryHandler, method: http.MethodGet, target: "/laporan/tagging_pokin/summary",
+			status: http.StatusBadRequest, code: errCodeInvalidParam, fields: []string{"nama_tagging", "tahun"}},
+		{name: "batch body bukan JSON", handler: srv.getDetailBatchHandler, method: http.MethodPost, target: "/tagging/getDetailBatch",
+			body: "{", status: http.StatusBadRequest, code: errCodeInvalidBody},
+		{name: "batch kode kosong", handler: srv.getDetailBatchHandler, method: http.MethodPost, target: "/tagging/getDetailBatch",
+			body: `{"kode_program_unggulan":[]}`, status: http.StatusBadRequest, code: errCodeInvalidParam,
+			fields: []string{"kode_program_unggulan"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			tt.handler(rec, httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body)))
+
+			apiErr := decodeError(t, rec, tt.status, tt.code)
+			var fields []string
+			for _, f := range apiErr.Fields {
+				fields = append(fields, f.Field)
+				if f.Message == "" {
+					t.Errorf("field %s tanpa message", f.Field)
+				}
+			}
+			if !slices.Equal(fields, tt.fields) {
+				t.Errorf("fields = %v, want %v", fields, tt.fields)
+			}
+		})
+	}
+}
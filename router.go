@@ -0,0 +1,53 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+)
+
+// routeMiddleware membungkus handler satu route, route berisi pola path yang didaftarkan
+type routeMiddleware func(route string, next http.HandlerFunc) http.HandlerFunc
+
+// router membungkus http.ServeMux (pola Go 1.22: "GET /tagging/getDetail/{kode}").
+// Path yang tidak terdaftar dibalas 404 JSON, method yang tidak didukung 405 JSON dengan header Allow.
+type router struct {
+	mux         *http.ServeMux
+	middlewares []routeMiddleware
+	methods     map[string][]string
+}
+
+func newRouter(middlewares ...routeMiddleware) *router {
+	rt := &router{
+		mux:         http.NewServeMux(),
+		middlewares: middlewares,
+		methods:     make(map[string][]string),
+	}
+	rt.mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, http.StatusNotFound, errCodeNotFound, "endpoint tidak ditemukan")
+	})
+	return rt
+}
+
+// handle mendaftarkan handler untuk method dan path, path boleh berisi parameter {nama}
+func (rt *router) handle(method, path string, h http.HandlerFunc) {
+	for i := len(rt.middlewares) - 1; i >= 0; i-- {
+		h = rt.middlewares[i](path, h)
+	}
+	rt.mux.HandleFunc(method+" "+path, h)
+
+	// pola tanpa method kalah spesifik dari pola ber-method,
+	// jadi hanya kena untuk method yang tidak terdaftar
+	if _, ok := rt.methods[path]; !ok {
+		rt.mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+			allowed := rt.methods[path]
+			w.Header().Set("Allow", strings.Join(allowed, ", "))
+			writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed,
+				"method tidak didukung, gunakan "+strings.Join(allowed, "/"))
+		})
+	}
+	rt.methods[path] = append(rt.methods[path], method)
+}
+
+func (rt *router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rt.mux.ServeHTTP(w, r)
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRouter(t *testing.T) {
+	ok := func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, Response{Status: http.StatusOK, Message: "ok", Data: r.PathValue("kode")})
+	}
+	rt := newRouter()
+	rt.handle(http.MethodGet, "/laporan", ok)
+	rt.handle(http.MethodPost, "/laporan", ok)
+	rt.handle(http.MethodGet, "/detail/{kode}", ok)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		status int
+		code   string
+		allow  string
+		data   any
+	}{
+		{name: "GET terdaftar", method: http.MethodGet, path: "/laporan", status: http.StatusOK},
+		{name: "POST terdaftar", method: http.MethodPost, path: "/laporan", status: http.StatusOK},
+		{name: "parameter path", method: http.MethodGet, path: "/detail/PU-01", status: http.StatusOK, data: "PU-01"},
+		{name: "path tidak ada", method: http.MethodGet, path: "/tidak-ada", status: http.StatusNotFound, code: errCodeNotFound},
+		{name: "sub path tidak ada", method: http.MethodGet, path: "/laporan/x", status: http.StatusNotFound, code: errCodeNotFound},
+		{name: "method tidak didukung", method: http.MethodDelete, path: "/laporan", status: http.StatusMethodNotAllowed,
+			code: errCodeMethodNotAllowed, allow: "GET, POST"},
+		{name: "method tidak didukung dengan parameter", method: http.MethodPost, path: "/detail/PU-01", status: http.StatusMethodNotAllowed,
+			code: errCodeMethodNotAllowed, allow: "GET"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			rt.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.status)
+			}
+			if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+				t.Errorf("Content-Type = %q, want application/json", ct)
+			}
+			if got := rec.Header().Get("Allow"); got != tt.allow {
+				t.Errorf("Allow = %q, want %q", got, tt.allow)
+			}
+
+			var resp Response
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("body bukan JSON: %v\n%s", err, rec.Body)
+			}
+			if resp.Status != tt.status {
+				t.Errorf("status di body = %d, want %d", resp.Status, tt.status)
+			}
+			if tt.code == "" {
+				if resp.Error != nil {
+					t.Errorf("error = %+v, want nil", resp.Error)
+				}
+				if tt.data != nil && resp.Data != tt.data {
+					t.Errorf("data = %v, want %v", resp.Data, tt.data)
+				}
+				return
+			}
+			if resp.Error == nil || resp.Error.Code != tt.code {
+				t.Errorf("error = %+v, want code %s", resp.Error, tt.code)
+			}
+		})
+	}
+}
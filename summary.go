@@ -0,0 +1,194 @@
+package main
+
+import (
+	"cmp"
+	"net/http"
+	"slices"
+	"strconv"
+)
+
+// SummaryCount jumlah data satu kelompok ringkasan.
+// Pokin, pelaksana (NIP), rekin dan indikator dihitung unik, pagu dijumlah per rekin unik,
+// jadi pokin yang muncul di lebih dari satu tagging tidak terhitung dua kali.
+type SummaryCount struct {
+	JumlahPokin     int   `json:"jumlah_pokin"`
+	JumlahPelaksana int   `json:"jumlah_pelaksana"`
+	JumlahRekin     int   `json:"jumlah_rekin"`
+	JumlahIndikator int   `json:"jumlah_indikator"`
+	TotalPagu       int64 `json:"total_pagu"`
+}
+
+type SummaryGroup struct {
+	Kode string `json:"kode"`
+	Nama string `json:"nama"`
+	SummaryCount
+}
+
+// SummaryLaporan ringkasan laporan tagging satu nama_tagging dan tahun.
+// Per program unggulan berisi kode tagging dari tag source (kegiatan utama untuk RB).
+// Satu pokin bisa punya beberapa bidang urusan, jumlah per bidang urusan bisa melebihi total.
+type SummaryLaporan struct {
+	NamaTagging        string         `json:"nama_tagging"`
+	Tahun              Tahun          `json:"tahun"`
+	Total              SummaryCount   `json:"total"`
+	PerOpd             []SummaryGroup `json:"per_opd"`
+	PerProgramUnggulan []SummaryGroup `json:"per_program_unggulan"`
+	PerJenisPohon      []SummaryGroup `json:"per_jenis_pohon"`
+	PerBidangUrusan    []SummaryGroup `json:"per_bidang_urusan"`
+}
+
+// summaryHandler GET /laporan/tagging_pokin/summary, parameter dan filter sama dengan laporan,
+// page, size dan sort diabaikan karena ringkasan selalu atas semua pokin, bulanan tidak dipakai
+func (s *server) summaryHandler(w http.ResponseWriter, r *http.Request) {
+	params, fields := parseLaporanParams(r.URL.Query())
+	if len(fields) > 0 {
+		writeError(w, http.StatusBadRequest, errCodeInvalidParam, "parameter tidak valid", fields...)
+		return
+	}
+	params.Query.Page, params.Query.Size, params.Query.Sort = 0, 0, nil
+	params.Bulanan = false
+
+	listPokin, _, warnings, err := s.loadLaporan(r.Context(), params)
+	if err != nil {
+		writeQueryError(w, r, err)
+		return
+	}
+
+	if len(warnings) > 0 {
+		w.Header().Set("Cache-Control", "no-store")
+	}
+
+	writeJSON(w, http.StatusOK, Response{
+		Status:   http.StatusOK,
+		Message:  "Ringkasan Laporan Tagging Pohon Kinerja",
+		Data:     summarize(params.NamaTagging, Tahun(params.Tahun), listPokin),
+		Warnings: warnings,
+	})
+}
+
+func summarize(namaTagging string, tahun Tahun, pokins []Pokin) SummaryLaporan {
+	total := newSummaryAcc("", "")
+	perOpd := summaryGroups{}
+	perProgram := summaryGroups{}
+	perJenis := summaryGroups{}
+	perBidang := summaryGroups{}
+
+	for _, p := range pokins {
+		total.add(p, nil)
+		perOpd.get(p.KodeOpd, p.NamaOpd).add(p, nil)
+		perProgram.get(p.KodeProgramUnggulan, p.NamaProgramUnggulan).add(p, nil)
+		perJenis.get(string(p.JenisPohon), string(p.JenisPohon)).add(p, nil)
+
+		if len(p.BidangUrusans) == 0 {
+			perBidang.get("-", "Tanpa Bidang Urusan").add(p, nil)
+		}
+		for _, b := range p.BidangUrusans {
+			// rekin masuk bidang urusan yang kodenya awalan subkegiatan, rekin tanpa subkegiatan masuk semua
+			perBidang.get(b.KodeBidangUrusan, b.NamaBidangUrusan).add(p, func(rk RencanaKinerjaAsn) bool {
+				return rk.KodeSubkegiatan == "" || hasKodePrefix(rk.KodeSubkegiatan, b.KodeBidangUrusan)
+			})
+		}
+	}
+
+	perJenisList := perJenis.list()
+	slices.SortStableFunc(perJenisList, func(a, b SummaryGroup) int {
+		return cmp.Compare(jenisPohonLevel(JenisPohon(a.Kode)), jenisPohonLevel(JenisPohon(b.Kode)))
+	})
+
+	return SummaryLaporan{
+		NamaTagging:        namaTagging,
+		Tahun:              tahun,
+		Total:              total.count(),
+		PerOpd:             perOpd.list(),
+		PerProgramUnggulan: perProgram.list(),
+		PerJenisPohon:      perJenisList,
+		PerBidangUrusan:    perBidang.list(),
+	}
+}
+
+func hasKodePrefix(kode, prefix string) bool {
+	return len(kode) > len(prefix) && kode[:len(prefix)] == prefix && kode[len(prefix)] == '.'
+}
+
+// summaryAcc menampung id unik satu kelompok
+type summaryAcc struct {
+	kode, nama string
+	pokins     map[int]struct{}
+	pelaksanas map[string]struct{}
+	rekins     map[string]struct{}
+	indikators map[string]struct{}
+	pagu       int64
+}
+
+func newSummaryAcc(kode, nama string) *summaryAcc {
+	return &summaryAcc{
+		kode:       kode,
+		nama:       nama,
+		pokins:     make(map[int]struct{}),
+		pelaksanas: make(map[string]struct{}),
+		rekins:     make(map[string]struct{}),
+		indikators: make(map[string]struct{}),
+	}
+}
+
+// add menghitung pokin beserta isinya, rekin bisa dibatasi dengan filter (nil = semua).
+// Pelaksana hanya dihitung jika punya rekin yang lolos filter.
+func (a *summaryAcc) add(p Pokin, filter func(RencanaKinerjaAsn) bool) {
+	a.pokins[p.IdPohon] = struct{}{}
+	for _, ind := range p.Indikator {
+		a.indikators[strconv.Itoa(p.IdPohon)+"/"+ind.IdIndikator] = struct{}{}
+	}
+	for _, pel := range p.Pelaksanas {
+		counted := filter == nil
+		for _, rk := range pel.RencanaKinerjas {
+			if filter != nil && !filter(rk) {
+				continue
+			}
+			counted = true
+			if _, ok := a.rekins[rk.IdRekin]; !ok {
+				a.rekins[rk.IdRekin] = struct{}{}
+				a.pagu += int64(rk.Pagu)
+			}
+		}
+		if counted {
+			a.pelaksanas[pel.NIPPelaksana] = struct{}{}
+		}
+	}
+}
+
+func (a *summaryAcc) count() SummaryCount {
+	return SummaryCount{
+		JumlahPokin:     len(a.pokins),
+		JumlahPelaksana: len(a.pelaksanas),
+		JumlahRekin:     len(a.rekins),
+		JumlahIndikator: len(a.indikators),
+		TotalPagu:       a.pagu,
+	}
+}
+
+// summaryGroups kelompok per kode, nama diambil dari kemunculan pertama
+type summaryGroups map[string]*summaryAcc
+
+func (g summaryGroups) get(kode, nama string) *summaryAcc {
+	if kode == "" {
+		kode = "-"
+	}
+	acc, ok := g[kode]
+	if !ok {
+		acc = newSummaryAcc(kode, nama)
+		g[kode] = acc
+	}
+	return acc
+}
+
+// list urut kode supaya response stabil
+func (g summaryGroups) list() []SummaryGroup {
+	groups := make([]SummaryGroup, 0, len(g))
+	for _, acc := range g {
+		groups = append(groups, SummaryGroup{Kode: acc.kode, Nama: acc.nama, SummaryCount: acc.count()})
+	}
+	slices.SortFunc(groups, func(a, b SummaryGroup) int {
+		return cmp.Compare(a.Kode, b.Kode)
+	})
+	return groups
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+)
+
+// TagSource menentukan tabel master yang dipakai satu tema tagging (program unggulan, RB, SPM, dst).
+// Baris tb_keterangan_tagging_program_unggulan.kode_program_unggulan di join ke tabel master ini.
+type TagSource interface {
+	// nama_tagging yang dilayani source ini
+	NamaTagging() []string
+	Master() TagMaster
+}
+
+// TagMaster mendeskripsikan tabel dan kolom master tagging.
+// Kolom kosong akan diisi '-' pada laporan, kecuali IdColumn yang diisi NULL.
+type TagMaster struct {
+	Table string `json:"table"`
+	// kolom yang dicocokkan dengan kode_program_unggulan
+	JoinColumn      string `json:"join_column"`
+	IdColumn        string `json:"id_column"`
+	KodeColumn      string `json:"kode_column"`
+	NamaColumn      string `json:"nama_column"`
+	DeskripsiColumn string `json:"deskripsi_column"`
+}
+
+type tableTagSource struct {
+	namaTagging []string
+	master      TagMaster
+}
+
+func (s tableTagSource) NamaTagging() []string { return s.namaTagging }
+func (s tableTagSource) Master() TagMaster     { return s.master }
+
+// TagRegistry memetakan nama_tagging ke TagSource,
+// nama_tagging yang tidak terdaftar memakai source default (program unggulan).
+type TagRegistry struct {
+	sources  map[string]TagSource
+	fallback TagSource
+}
+
+func (r *TagRegistry) Lookup(namaTagging string) TagSource {
+	if src, ok := r.sources[namaTagging]; ok {
+		return src
+	}
+	return r.fallback
+}
+
+// isi file konfigurasi tag source
+type tagSourceConfig struct {
+	Default TagMaster `json:"default"`
+	Sources []struct {
+		NamaTagging []string `json:"nama_tagging"`
+		TagMaster
+	} `json:"sources"`
+}
+
+// konfigurasi bawaan, sama dengan perilaku lama: RB ke datamaster_rb, sisanya ke tb_program_unggulan
+//
+//go:embed tag_sources.json
+var defaultTagSources []byte
+
+// LoadTagRegistry membaca konfigurasi tag source dari file
+func LoadTagRegistry(path string) (*TagRegistry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("baca tag source %s: %w", path, err)
+	}
+	return ParseTagRegistry(data)
+}
+
+func ParseTagRegistry(data []byte) (*TagRegistry, error) {
+	var cfg tagSourceConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("parse tag source: %w", err)
+	}
+
+	if err := cfg.Default.validate(); err != nil {
+		return nil, fmt.Errorf("tag source default: %w", err)
+	}
+
+	reg := &TagRegistry{
+		sources:  make(map[string]TagSource),
+		fallback: tableTagSource{master: cfg.Default},
+	}
+	for i, src := range cfg.Sources {
+		if len(src.NamaTagging) == 0 {
+			return nil, fmt.Errorf("tag source #%d: nama_tagging wajib diisi", i)
+		}
+		if err := src.TagMaster.validate(); err != nil {
+			return nil, fmt.Errorf("tag source %q: %w", src.NamaTagging[0], err)
+		}
+
+		ts := tableTagSource{namaTagging: src.NamaTagging, master: src.TagMaster}
+		for _, nama := range src.NamaTagging {
+			if _, dup := reg.sources[nama]; dup {
+				return nil, fmt.Errorf("tag source %q terdaftar lebih dari sekali", nama)
+			}
+			reg.sources[nama] = ts
+		}
+	}
+
+	return reg, nil
+}
+
+var sqlIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// nama tabel dan kolom disisipkan langsung ke query, jadi wajib identifier polos
+func (m TagMaster) validate() error {
+	if !sqlIdentifier.MatchString(m.Table) {
+		return fmt.Errorf("table %q tidak valid", m.Table)
+	}
+	if !sqlIdentifier.MatchString(m.JoinColumn) {
+		return fmt.Errorf("join_column %q tidak valid", m.JoinColumn)
+	}
+	for name, col := range map[string]string{
+		"id_column":        m.IdColumn,
+		"kode_column":      m.KodeColumn,
+		"nama_column":      m.NamaColumn,
+		"deskripsi_column": m.DeskripsiColumn,
+	} {
+		if col != "" && !sqlIdentifier.MatchString(col) {
+			return fmt.Errorf("%s %q tidak valid", name, col)
+		}
+	}
+	return nil
+}
+
+// ekspresi select untuk kolom master, '-' jika kolom tidak dikonfigurasi
+func (m TagMaster) selectColumn(col string) string {
+	if col == "" {
+		return "'-'"
+	}
+	return "master." + col
+}
+
+func (m TagMaster) selectIdColumn() string {
+	if m.IdColumn == "" {
+		return "NULL"
+	}
+	return "master." + m.IdColumn
+}
@@ -6,9 +6,14 @@ type Keterangan string
 type Pagu int
 
 type Response struct {
-	Status  int    `json:"status"`
-	Message string `json:"message"`
-	Data    any    `json:"data"`
+	Status  int       `json:"status"`
+	Message string    `json:"message"`
+	Data    any       `json:"data"`
+	Error   *APIError `json:"error,omitempty"`
+	// langkah pelengkap data yang gagal, hanya terisi jika request memakai partial=true
+	Warnings []Warning `json:"warnings,omitempty"`
+	// total dan halaman untuk response list
+	Meta *Meta `json:"meta,omitempty"`
 }
 
 type TagPokin struct {
@@ -34,6 +39,9 @@ type Pokin struct {
 	Pelaksanas          []PelaksanaPokin `json:"pelaksanas"`
 	Keterangan          string           `json:"keterangan"`
 	Indikator           []IndikatorPohon `json:"indikator"`
+	// semua bidang urusan dan program dari subkegiatan rekin operational di bawah pokin ini
+	BidangUrusans []BidangUrusan `json:"bidang_urusans"`
+	Programs      []Program      `json:"programs"`
 }
 
 type IndikatorPohon struct {
@@ -53,7 +61,7 @@ type TargetIndikator struct {
 }
 
 type PelaksanaPokin struct {
-	IdPelaksana     string
+	IdPelaksana     string              `json:"id_pelaksana"`
 	NamaPelaksana   string              `json:"nama_pelaksana"`
 	NIPPelaksana    string              `json:"nip_pelaksana"`
 	RencanaKinerjas []RencanaKinerjaAsn `json:"rencana_kinerjas"`
@@ -65,20 +73,26 @@ type Subkegiatan struct {
 }
 
 type RencanaKinerjaAsn struct {
-	IdRekin            string             `json:"id_rekin"`
-	RencanaKinerja     string             `json:"rencana_kinerja"`
-	NamaPelaksana      string             `json:"nama_pelaksana"`
-	NIPPelaksana       string             `json:"nip_pelaksana"`
-	KodeBidangUrusan   string             `json:"kode_bidang_urusan"`
-	NamaBidangUrusan   string             `json:"nama_bidang_urusan"`
-	KodeProgram        string             `json:"kode_program"`
-	NamaProgram        string             `json:"nama_program"`
+	IdRekin          string `json:"id_rekin"`
+	RencanaKinerja   string `json:"rencana_kinerja"`
+	NamaPelaksana    string `json:"nama_pelaksana"`
+	NIPPelaksana     string `json:"nip_pelaksana"`
+	KodeBidangUrusan string `json:"kode_bidang_urusan"`
+	NamaBidangUrusan string `json:"nama_bidang_urusan"`
+	KodeProgram      string `json:"kode_program"`
+	NamaProgram      string `json:"nama_program"`
+	// semua bidang urusan dan program yang cocok, kode di atas "-" jika lebih dari satu
+	BidangUrusans      []BidangUrusan     `json:"bidang_urusans,omitempty"`
+	Programs           []Program          `json:"programs,omitempty"`
 	IndikatorPrograms  []IndikatorProgram `json:"indikator_programs"`
 	KodeSubkegiatan    string             `json:"kode_subkegiatan"`
 	NamaSubkegiatan    string             `json:"nama_subkegiatan"`
 	Pagu               Pagu               `json:"pagu"`
 	Catatan            string             `json:"keterangan"`
 	TahapanPelaksanaan WaktuPelaksanaan   `json:"tahapan_pelaksanaan"`
+	// hanya diisi dengan ?bulanan=true: bobot 12 bulan (jumlah semua rencana aksi) dan per rencana aksi
+	BobotBulanan []BulanBobot  `json:"bobot_bulanan,omitempty"`
+	RencanaAksis []RencanaAksi `json:"rencana_aksis,omitempty"`
 }
 
 type IndikatorProgram struct {
@@ -93,8 +107,15 @@ type WaktuPelaksanaan struct {
 }
 
 type BulanBobot struct {
-	Bulan int
-	Bobot int
+	Bulan int `json:"bulan"`
+	Bobot int `json:"bobot"`
+}
+
+// RencanaAksi jadwal bulanan satu rencana aksi rekin, TahapanPelaksanaan jumlah per triwulan
+type RencanaAksi struct {
+	IdRencanaAksi      string           `json:"id_rencana_aksi"`
+	BobotBulanan       []BulanBobot     `json:"bobot_bulanan"`
+	TahapanPelaksanaan WaktuPelaksanaan `json:"tahapan_pelaksanaan"`
 }
 
 type BidangUrusan struct {
@@ -105,5 +126,5 @@ type BidangUrusan struct {
 type Program struct {
 	KodeProgram      string           `json:"kode_program"`
 	NamaProgram      string           `json:"nama_program"`
-	IndikatorProgram []IndikatorPohon `json:"indikator"`
+	IndikatorProgram []IndikatorPohon `json:"indikator,omitempty"`
 }